@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Exit code when there are differences, so CI gates can tell drift apart
+// from other failures.
+const diffExitCodeDrift = 3
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare fetched values against an env file or another config",
+	Long: `Compare fetched values against an env file or another config.
+
+Names only fetched are shown as added (+), names only in the env file or
+other config as removed (-), and names in both with different values as
+changed (~). Values are redacted unless --values is hash or plain.
+
+Exits with code 3 when there are differences.`,
+	Run: diff,
+}
+
+// Initialize the diff CLI subcommand
+func init() {
+
+	// against
+	defaultAgainst := viper.GetViper().GetString(core.OptStr_DiffAgainst)
+	diffCmd.PersistentFlags().String("against", defaultAgainst, "Env file to compare fetched values against")
+	err := viper.BindPFlag(core.OptStr_DiffAgainst, diffCmd.PersistentFlags().Lookup("against"))
+	if err != nil {
+		panic(err)
+	}
+
+	// against-config
+	defaultAgainstConfig := viper.GetViper().GetString(core.OptStr_DiffAgainstConfig)
+	diffCmd.PersistentFlags().String("against-config", defaultAgainstConfig, "Config file to fetch and compare values against")
+	err = viper.BindPFlag(core.OptStr_DiffAgainstConfig, diffCmd.PersistentFlags().Lookup("against-config"))
+	if err != nil {
+		panic(err)
+	}
+
+	// values
+	defaultValues := viper.GetViper().GetString(core.OptStr_DiffValues)
+	diffCmd.PersistentFlags().String("values", defaultValues, "How to show values (redacted, hash, plain)")
+	err = viper.BindPFlag(core.OptStr_DiffValues, diffCmd.PersistentFlags().Lookup("values"))
+	if err != nil {
+		panic(err)
+	}
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+// Top level logic for the diff CLI subcommand
+func diff(cmd *cobra.Command, args []string) {
+	ShowBanner()
+
+	against := viper.GetString(core.OptStr_DiffAgainst)
+	againstConfig := viper.GetString(core.OptStr_DiffAgainstConfig)
+	valueMode := viper.GetString(core.OptStr_DiffValues)
+
+	if (against == "") == (againstConfig == "") {
+		core.PrintFatal("exactly one of --against or --against-config is required", 1)
+	}
+
+	if countRemoteTargets() == 0 {
+		core.PrintFatal("no remote values to fetch were specified", 1)
+	}
+
+	current := variable.VariableValues(fetchVariables(), formatOptions())
+
+	var againstValues map[string]string
+	var againstName string
+	if against != "" {
+		againstValues = readEnvFileValues(against)
+		againstName = against
+	} else {
+		againstValues = fetchConfigValues(againstConfig)
+		againstName = againstConfig
+	}
+
+	differences := variable.DiffValues(current, againstValues)
+	output, err := variable.FormatDiff(differences, valueMode)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	core.PrintNormal(fmt.Sprintf("\nComparing fetched values against %s\n\n", againstName))
+	if len(differences) == 0 {
+		core.PrintNormal("No differences\n")
+		return
+	}
+
+	core.PrintAlways(output)
+	core.PrintAlways("\n")
+	os.Exit(diffExitCodeDrift)
+}
+
+// Read the values in a local env file, written in the configured dialect.
+func readEnvFileValues(path string) map[string]string {
+	content, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to read %s: %s", path, err), 1)
+	}
+
+	values, err := variable.ParseEnvFile(string(content), viper.GetString(core.OptStr_Dialect))
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to parse %s: %s", path, err), 1)
+	}
+
+	return values
+}
+
+// Fetch the values configured in another config file.
+//
+// Values are formatted with the current settings, so both sides name
+// variables the same way.
+func fetchConfigValues(path string) map[string]string {
+	other, err := readOtherConfig(path)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	if countConfigTargets(other) == 0 {
+		core.PrintFatal(fmt.Sprintf("no remote values to fetch were specified in %s", path), 1)
+	}
+
+	variables, err := tryFetchConfigVariables(other)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	return variable.VariableValues(variables, formatOptions())
+}
+
+// Read another config file into its own viper instance, leaving the
+// current config untouched.
+//
+// Settings the file doesn't set fall back to the current ones (flags,
+// environment variables, config file and defaults), except the targets to
+// fetch, which only come from the file.
+func readOtherConfig(path string) (*viper.Viper, error) {
+	other := viper.New()
+	for _, key := range viper.AllKeys() {
+		if key == core.OptStr_AWS_SsmParameterStore || key == core.OptStr_AWS_SecretManager {
+			continue
+		}
+		other.SetDefault(key, viper.Get(key))
+	}
+
+	other.SetConfigFile(path)
+	other.SetConfigType("yaml")
+	if err := other.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return other, nil
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestReadOtherConfig(t *testing.T) {
+	core.InitConfigDefaults()
+	viper.SetDefault(core.OptStr_AWS_SsmParameterStore, []string{"/current/*"})
+	viper.SetDefault(core.OptStr_NameReplacement, "__")
+	t.Cleanup(core.InitConfigDefaults)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "other.yaml")
+	content := "aws:\n  sm_secret:\n  - other/secret\ntransform:\n  lower: true\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := readOtherConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Targets only come from the other file.
+	if targets := other.GetStringSlice(core.OptStr_AWS_SsmParameterStore); len(targets) != 0 {
+		t.Errorf("other config has SSM targets %v, want none", targets)
+	}
+	if count := countConfigTargets(other); count != 1 {
+		t.Errorf("other config has %d targets, want 1", count)
+	}
+
+	// Its settings win, and the others fall back to the current ones.
+	if !other.GetBool(core.OptStr_ToLower) {
+		t.Errorf("other config didn't set %s", core.OptStr_ToLower)
+	}
+	if replacement := other.GetString(core.OptStr_NameReplacement); replacement != "__" {
+		t.Errorf("other config has %s %q, want the current %q", core.OptStr_NameReplacement, replacement, "__")
+	}
+
+	// The current config is untouched.
+	if viper.GetBool(core.OptStr_ToLower) {
+		t.Errorf("current config picked up %s", core.OptStr_ToLower)
+	}
+	if count := countRemoteTargets(); count != 1 {
+		t.Errorf("current config has %d targets, want 1", count)
+	}
+	if used := viper.ConfigFileUsed(); used == path {
+		t.Errorf("current config file changed to %s", used)
+	}
+}
+
+func TestReadOtherConfigMissing(t *testing.T) {
+	if _, err := readOtherConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("reading a missing config file succeeded")
+	}
+}
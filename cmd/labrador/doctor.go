@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/aws"
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the config, credentials and access to each target",
+	Long: `Diagnose the config, credentials and access to each target.
+
+Shows the config file in use, the effective settings and where each one
+comes from, the AWS identity for each region and profile, and whether each
+target can be read. Values are read to check access, but never shown.`,
+	Args: cobra.NoArgs,
+	Run:  doctor,
+}
+
+// Settings shown by doctor, with the global flag that sets each one, if any.
+var doctorSettings = []struct {
+	key  string
+	flag string
+}{
+	{core.OptStr_AWS_Region, "aws-region"},
+	{core.OptStr_AWS_SsmParameterStore, "aws-param"},
+	{core.OptStr_AWS_SsmNaming, "aws-param-naming"},
+	{core.OptStr_AWS_SecretManager, "aws-secret"},
+	{core.OptStr_Include, "include"},
+	{core.OptStr_Exclude, "exclude"},
+	{core.OptStr_Rename, ""},
+	{core.OptStr_NameReplacement, "name-replacement"},
+	{core.OptStr_InvalidNames, "invalid-names"},
+	{core.OptStr_Quote, "quote"},
+	{core.OptStr_ToLower, "lower"},
+	{core.OptStr_ToUpper, "upper"},
+	{core.OptStr_Sort, "sort"},
+	{core.OptStr_Format, ""},
+	{core.OptStr_Dialect, ""},
+	{core.OptStr_OutFile, ""},
+	{core.OptStr_Outputs, ""},
+}
+
+// AWS SDK environment variables that affect credentials and regions.
+var doctorAwsEnvVars = []string{
+	"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ACCESS_KEY_ID",
+	"AWS_CONFIG_FILE", "AWS_SHARED_CREDENTIALS_FILE", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE",
+}
+
+// Initialize the doctor CLI subcommand
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// Top level logic for the doctor CLI subcommand
+func doctor(cmd *cobra.Command, args []string) {
+	ShowBanner()
+	healthy := true
+
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		configFile = "none found"
+	}
+	core.PrintAlways(fmt.Sprintf("\nConfig file: %s\n", configFile))
+
+	core.PrintAlways("\nSettings:\n")
+	core.PrintAlways(doctorSettingsTable(cmd))
+
+	core.PrintAlways("\nAWS environment:\n")
+	for _, name := range doctorAwsEnvVars {
+		if _, set := os.LookupEnv(name); set {
+			// Only names are shown, since some of these are credentials.
+			core.PrintAlways(fmt.Sprintf("  %s is set\n", name))
+		}
+	}
+
+	ssmTargets, err := core.GetTargets(core.OptStr_AWS_SsmParameterStore)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	smTargets, err := core.GetTargets(core.OptStr_AWS_SecretManager)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	if len(ssmTargets)+len(smTargets) == 0 {
+		core.PrintAlways("\nNo targets are configured\n")
+		return
+	}
+
+	core.PrintAlways("\nAWS identities:\n")
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	for _, identity := range aws.GetIdentities(append(append([]core.Target{}, ssmTargets...), smTargets...)) {
+		location := doctorLocation(identity.Region, identity.Profile)
+		if identity.Error != nil {
+			healthy = false
+			fmt.Fprintf(writer, "  %s\tFAIL\t%s\n", location, aws.ErrorSummary(identity.Error))
+			continue
+		}
+		fmt.Fprintf(writer, "  %s\tok\t%s (account %s)\n", location, identity.Arn, identity.Account)
+	}
+	_ = writer.Flush()
+	core.PrintAlways(buffer.String())
+
+	core.PrintAlways("\nTargets:\n")
+	buffer.Reset()
+	writer = tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	probes := make([]aws.TargetProbe, 0)
+	for _, target := range ssmTargets {
+		probes = append(probes, aws.ProbeTarget(aws.Store_SsmParameterStore, target))
+	}
+	for _, target := range smTargets {
+		probes = append(probes, aws.ProbeTarget(aws.Store_SecretsManager, target))
+	}
+	for _, probe := range probes {
+		location := doctorLocation(probe.Region, probe.Profile)
+		if probe.Error != nil {
+			healthy = false
+			fmt.Fprintf(writer, "  %s\t%s\t%s\tFAIL\t%s\n", probe.Store, probe.Resource, location, aws.ErrorSummary(probe.Error))
+			continue
+		}
+		fmt.Fprintf(writer, "  %s\t%s\t%s\tok\n", probe.Store, probe.Resource, location)
+	}
+	_ = writer.Flush()
+	core.PrintAlways(buffer.String())
+
+	if !healthy {
+		core.PrintFatal("some checks failed", 1)
+	}
+}
+
+// Format the doctor settings as a table of values and their sources.
+func doctorSettingsTable(cmd *cobra.Command) string {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+
+	for _, setting := range doctorSettings {
+		value := fmt.Sprintf("%v", viper.Get(setting.key))
+		if value == "" || value == "<nil>" || value == "[]" {
+			value = "-"
+		}
+		source := settingSource(viper.GetViper(), cmd, setting.key, setting.flag)
+		fmt.Fprintf(writer, "  %s\t%s\t(%s)\n", setting.key, value, source)
+	}
+
+	_ = writer.Flush()
+	return buffer.String()
+}
+
+// Where the effective value of a setting comes from, in viper's precedence order.
+func settingSource(v *viper.Viper, cmd *cobra.Command, key string, flag string) string {
+	if flag != "" && cmd.Flags().Changed(flag) {
+		return "flag --" + flag
+	}
+
+	envName := "LAB_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	if _, set := os.LookupEnv(envName); set {
+		return "env " + envName
+	}
+
+	if v.InConfig(key) {
+		return "file"
+	}
+
+	return "default"
+}
+
+// Describe a region and profile. The profile is empty when the AWS SDK
+// configuration couldn't be loaded to resolve it.
+func doctorLocation(region string, profile string) string {
+	if region == "" {
+		region = "no region"
+	}
+	if profile == "" {
+		return region
+	}
+	return fmt.Sprintf("%s, profile %s", region, profile)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestSettingSource(t *testing.T) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	content := "aws:\n  region: us-east-1\n  ssm_param:\n  - /app/*\ntransform:\n  lower: true\n"
+	if err := v.ReadConfig(strings.NewReader(content)); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	cmd.Flags().String("aws-region", "", "")
+	cmd.Flags().Bool("lower", false, "")
+	cmd.Flags().String("quote", "", "")
+	if err := cmd.Flags().Set("aws-region", "eu-west-1"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LAB_AWS_SSM_PARAM", "/other/*")
+
+	tests := []struct {
+		key  string
+		flag string
+		want string
+	}{
+		// A flag wins over the environment and the config file.
+		{key: core.OptStr_AWS_Region, flag: "aws-region", want: "flag --aws-region"},
+		{key: core.OptStr_AWS_SsmParameterStore, flag: "aws-param", want: "env LAB_AWS_SSM_PARAM"},
+		{key: core.OptStr_ToLower, flag: "lower", want: "file"},
+		{key: core.OptStr_Quote, flag: "quote", want: "default"},
+		// Settings without a flag.
+		{key: core.OptStr_Rename, want: "default"},
+	}
+	for _, test := range tests {
+		if got := settingSource(v, cmd, test.key, test.flag); got != test.want {
+			t.Errorf("settingSource(%s) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestDoctorLocation(t *testing.T) {
+	t.Setenv("AWS_PROFILE", "from-env")
+
+	tests := []struct {
+		region  string
+		profile string
+		want    string
+	}{
+		{region: "us-east-1", profile: "default", want: "us-east-1, profile default"},
+		{region: "", profile: "dev", want: "no region, profile dev"},
+		// Without a resolved profile, AWS_PROFILE isn't guessed.
+		{region: "us-east-1", profile: "", want: "us-east-1"},
+	}
+	for _, test := range tests {
+		if got := doctorLocation(test.region, test.profile); got != test.want {
+			t.Errorf("doctorLocation(%q, %q) = %q, want %q", test.region, test.profile, got, test.want)
+		}
+	}
+}
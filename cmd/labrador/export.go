@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"fmt"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
@@ -17,6 +19,15 @@ var exportCmd = &cobra.Command{
 
 // Initialize the export CLI subcommand
 func init() {
+
+	// shell
+	defaultShell := viper.GetViper().GetString(core.OptStr_Shell)
+	exportCmd.PersistentFlags().String("shell", defaultShell, "Shell syntax to export with (posix, bash, zsh, fish, powershell, cmd)")
+	err := viper.BindPFlag(core.OptStr_Shell, exportCmd.PersistentFlags().Lookup("shell"))
+	if err != nil {
+		panic(err)
+	}
+
 	rootCmd.AddCommand(exportCmd)
 }
 
@@ -29,15 +40,11 @@ func export(cmd *cobra.Command, args []string) {
 		core.PrintFatal("no remote values to fetch were specified", 1)
 	}
 
-	variables := make(map[string]*variable.Variable, 0)
-	variables = fetchAwsSsmParameters(variables)
-	variables = fetchAwsSmSecrets(variables)
+	variables := fetchVariables()
 
-	toLower := viper.GetBool(core.OptStr_ToLower)
-	toUpper := viper.GetBool(core.OptStr_ToUpper)
-	formattedOutput, err := variable.VariablesAsShellExport(variables, toLower, toUpper)
+	formattedOutput, err := variable.Format(variables, variable.Format_Export, formatOptions())
 	if err != nil {
-		core.PrintFatal("failed to format variables as shell exports", 1)
+		core.PrintFatal(fmt.Sprintf("failed to format variables as shell exports: %s", err), 1)
 	}
 
 	// Display formatted results to STDOUT.
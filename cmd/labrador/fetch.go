@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -16,24 +17,22 @@ import (
 var fetchCmd = &cobra.Command{
 	Use:   "fetch",
 	Short: "Fetch values from services",
-	Long:  `Fetch values from services`,
-	Run:   fetch,
+	Long: `Fetch values from services.
+
+With --watch, values are fetched again every --interval, and the outputs are
+rewritten whenever the version of a value changes, or values are added or
+removed. After a rewrite, the --on-change command is run, and the --signal is
+sent to --signal-pid.
+
+Watched outputs are replaced with only the fetched values, never appended to,
+so lines added by hand to an existing file like .env are lost on the first
+write. Keep them in a separate file.`,
+	Run: fetch,
 }
 
 // Initialize the fetch CLI subcommand
 func init() {
 
-	// json
-	// TODO: implement exporting results to JSON.
-	/*
-		defaultOutJSON := viper.GetBool(core.OptStr_OutJSON)
-		rootCmd.PersistentFlags().Bool("json", defaultOutJSON, "Use JSON output")
-		err = viper.BindPFlag(core.OptStr_OutJSON, rootCmd.PersistentFlags().Lookup("json"))
-		if err != nil {
-			panic(err)
-		}
-	*/
-
 	// outfile
 	defaultOutFile := viper.GetViper().GetString(core.OptStr_OutFile)
 	fetchCmd.PersistentFlags().StringP("outfile", "o", defaultOutFile, "File path to write variable/value pairs to")
@@ -50,66 +49,282 @@ func init() {
 		panic(err)
 	}
 
+	// format
+	defaultFormat := viper.GetViper().GetString(core.OptStr_Format)
+	fetchCmd.PersistentFlags().StringP("format", "f", defaultFormat, "Output format (env, export, json, yaml, toml, k8s-secret, k8s-configmap, github, gitlab, properties, systemd, tfvars-json)")
+	err = viper.BindPFlag(core.OptStr_Format, fetchCmd.PersistentFlags().Lookup("format"))
+	if err != nil {
+		panic(err)
+	}
+
+	// nest
+	defaultNest := viper.GetViper().GetBool(core.OptStr_Nest)
+	fetchCmd.PersistentFlags().Bool("nest", defaultNest, "Nest structured output by splitting variable names on the separator")
+	err = viper.BindPFlag(core.OptStr_Nest, fetchCmd.PersistentFlags().Lookup("nest"))
+	if err != nil {
+		panic(err)
+	}
+
+	// separator
+	defaultSeparator := viper.GetViper().GetString(core.OptStr_Separator)
+	fetchCmd.PersistentFlags().String("separator", defaultSeparator, "Separator between nested variable name segments")
+	err = viper.BindPFlag(core.OptStr_Separator, fetchCmd.PersistentFlags().Lookup("separator"))
+	if err != nil {
+		panic(err)
+	}
+
+	// k8s-name
+	defaultK8sName := viper.GetViper().GetString(core.OptStr_K8sName)
+	fetchCmd.PersistentFlags().String("k8s-name", defaultK8sName, "Name of the Kubernetes Secret or ConfigMap")
+	err = viper.BindPFlag(core.OptStr_K8sName, fetchCmd.PersistentFlags().Lookup("k8s-name"))
+	if err != nil {
+		panic(err)
+	}
+
+	// k8s-namespace
+	defaultK8sNamespace := viper.GetViper().GetString(core.OptStr_K8sNamespace)
+	fetchCmd.PersistentFlags().String("k8s-namespace", defaultK8sNamespace, "Namespace of the Kubernetes Secret or ConfigMap")
+	err = viper.BindPFlag(core.OptStr_K8sNamespace, fetchCmd.PersistentFlags().Lookup("k8s-namespace"))
+	if err != nil {
+		panic(err)
+	}
+
+	// k8s-label
+	defaultK8sLabels := viper.GetViper().GetStringSlice(core.OptStr_K8sLabels)
+	fetchCmd.PersistentFlags().StringSlice("k8s-label", defaultK8sLabels, "Label for the Kubernetes Secret or ConfigMap (key=value)")
+	err = viper.BindPFlag(core.OptStr_K8sLabels, fetchCmd.PersistentFlags().Lookup("k8s-label"))
+	if err != nil {
+		panic(err)
+	}
+
+	// k8s-annotation
+	defaultK8sAnnotations := viper.GetViper().GetStringSlice(core.OptStr_K8sAnnotations)
+	fetchCmd.PersistentFlags().StringSlice("k8s-annotation", defaultK8sAnnotations, "Annotation for the Kubernetes Secret or ConfigMap (key=value)")
+	err = viper.BindPFlag(core.OptStr_K8sAnnotations, fetchCmd.PersistentFlags().Lookup("k8s-annotation"))
+	if err != nil {
+		panic(err)
+	}
+
+	// dialect
+	defaultDialect := viper.GetViper().GetString(core.OptStr_Dialect)
+	fetchCmd.PersistentFlags().String("dialect", defaultDialect, "Dotenv dialect to quote values for (plain, docker, node, python)")
+	err = viper.BindPFlag(core.OptStr_Dialect, fetchCmd.PersistentFlags().Lookup("dialect"))
+	if err != nil {
+		panic(err)
+	}
+
+	// watch
+	defaultWatch := viper.GetViper().GetBool(core.OptStr_Watch)
+	fetchCmd.PersistentFlags().Bool("watch", defaultWatch, "Keep fetching values, and rewrite the outputs when they change")
+	err = viper.BindPFlag(core.OptStr_Watch, fetchCmd.PersistentFlags().Lookup("watch"))
+	if err != nil {
+		panic(err)
+	}
+
+	// on-change
+	defaultOnChange := viper.GetViper().GetString(core.OptStr_OnChange)
+	fetchCmd.PersistentFlags().String("on-change", defaultOnChange, "Shell command to run after values change when watching")
+	err = viper.BindPFlag(core.OptStr_OnChange, fetchCmd.PersistentFlags().Lookup("on-change"))
+	if err != nil {
+		panic(err)
+	}
+
+	// signal-pid
+	defaultSignalPid := viper.GetViper().GetInt(core.OptStr_SignalPid)
+	fetchCmd.PersistentFlags().Int("signal-pid", defaultSignalPid, "Process to signal after values change when watching")
+	err = viper.BindPFlag(core.OptStr_SignalPid, fetchCmd.PersistentFlags().Lookup("signal-pid"))
+	if err != nil {
+		panic(err)
+	}
+
+	// signal
+	defaultSignal := viper.GetViper().GetString(core.OptStr_Signal)
+	fetchCmd.PersistentFlags().String("signal", defaultSignal, "Signal to send to --signal-pid after values change (default HUP)")
+	err = viper.BindPFlag(core.OptStr_Signal, fetchCmd.PersistentFlags().Lookup("signal"))
+	if err != nil {
+		panic(err)
+	}
+
+	initWatchFlags(fetchCmd)
+
 	rootCmd.AddCommand(fetchCmd)
 }
 
 // Top level logic for the fetch CLI subcommand
 func fetch(cmd *cobra.Command, args []string) {
+	bindWatchFlags(cmd)
 	ShowBanner()
 
 	if countRemoteTargets() == 0 {
 		core.PrintFatal("no remote values to fetch were specified", 1)
 	}
 
-	variables := make(map[string]*variable.Variable, 0)
-	variables = fetchAwsSsmParameters(variables)
-	variables = fetchAwsSmSecrets(variables)
+	outputs, err := core.GetOutputs(core.OptStr_Outputs)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	// Check the watch settings before the first fetch.
+	watching := viper.GetBool(core.OptStr_Watch)
+	var interval time.Duration
+	if watching {
+		interval, err = watchInterval()
+		if err != nil {
+			core.PrintFatal(err.Error(), 1)
+		}
+		if viper.GetInt(core.OptStr_SignalPid) != 0 {
+			if _, err := configuredSignal(); err != nil {
+				core.PrintFatal(err.Error(), 1)
+			}
+		} else if viper.GetString(core.OptStr_Signal) != "" {
+			core.PrintWarning("--signal is ignored without --signal-pid")
+		}
+		// Watched outputs are replaced, which would drop what earlier steps
+		// appended to $GITHUB_ENV.
+		if writesFormat(outputs, variable.Format_Github) {
+			core.PrintFatal("--watch can't be used with the github format, since $GITHUB_ENV is only appended to", 1)
+		}
+	}
+
+	variables := fetchVariables()
 
 	core.PrintDebug("\n")
 	core.PrintNormal(fmt.Sprintf("\nFetched %d values\n", len(variables)))
 	core.PrintDebug("\n")
 
-	formattedOutput := formatVariablesOutput(variables)
+	writeOutputs(variables, outputs, watching)
+
+	if watching {
+		watchVariables(variables, interval, func(current map[string]*variable.Variable, changed []string) {
+			writeOutputs(current, outputs, true)
+			runChangeHook(changed)
+			signalChangedProcess()
+		})
+	}
+}
 
+// Write the default output and the configured outputs.
+//
+// When watching, output files are replaced atomically instead of appended to.
+func writeOutputs(variables map[string]*variable.Variable, outputs []core.Output, watching bool) {
+	// Configured outputs replace the default output, unless an outfile is also given.
 	outFilePath := viper.GetString(core.OptStr_OutFile)
-	outFileMode := viper.GetString(core.OptStr_FileMode)
-	if outFilePath != "" {
-		// Dump formatted results to file.
-		writeFormattedOutFile(formattedOutput, outFilePath, outFileMode)
-		core.PrintNormal(fmt.Sprintf("Wrote parameters to file: %s\n", outFilePath))
-	} else {
-		// Display formatted results to STDOUT.
-		core.PrintAlways(formattedOutput)
-		core.PrintNormal("\n")
+	if len(outputs) == 0 || outFilePath != "" {
+		format := viper.GetString(core.OptStr_Format)
+		outFileMode := viper.GetString(core.OptStr_FileMode)
+		writeOutput(variables, format, outFilePath, outFileMode, formatOptions(), watching)
+	}
+
+	for _, output := range outputs {
+		writeConfiguredOutput(variables, output, watching)
 	}
 }
 
-// Convert the list of variables to formatted output.
-func formatVariablesOutput(variables map[string]*variable.Variable) string {
-	var formattedOutput string
-	var err error
+// Check if writeOutputs writes any output in a format.
+func writesFormat(outputs []core.Output, format string) bool {
+	if len(outputs) == 0 || viper.GetString(core.OptStr_OutFile) != "" {
+		if viper.GetString(core.OptStr_Format) == format {
+			return true
+		}
+	}
+	for _, output := range outputs {
+		if output.Format == format {
+			return true
+		}
+	}
+	return false
+}
 
-	useQuotes := viper.GetBool(core.OptStr_Quote)
-	toLower := viper.GetBool(core.OptStr_ToLower)
-	toUpper := viper.GetBool(core.OptStr_ToUpper)
+// Apply the rename rules and filters of a configured output, and write it.
+func writeConfiguredOutput(variables map[string]*variable.Variable, output core.Output, watching bool) {
+	outputVariables, err := variable.RenameVariables(variables, output.Rename)
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to rename variables for %s output: %s", output.Format, err), 1)
+	}
 
-	formattedOutput, err = variable.VariablesAsEnvFile(variables, useQuotes, toLower, toUpper)
+	opts, err := output.ApplyFormatOptions(formatOptions())
 	if err != nil {
-		core.PrintFatal("failed to format variables as env file", 1)
+		core.PrintFatal(fmt.Sprintf("invalid %s output: %s", output.Format, err), 1)
 	}
 
-	return formattedOutput
+	// Renamed variables need to be valid names too.
+	replacement := viper.GetString(core.OptStr_NameReplacement)
+	invalidNames := viper.GetString(core.OptStr_InvalidNames)
+	outputVariables, skipped, err := variable.SanitizeVariableNames(outputVariables, replacement, invalidNames, opts.Lower, opts.Upper)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	for _, name := range sortedReasonNames(skipped) {
+		core.PrintWarning(fmt.Sprintf("skipping variable %q in %s output: %s", name, output.Format, skipped[name]))
+	}
+
+	outputVariables, filteredOut, err := variable.FilterVariables(outputVariables, output.KeyFilter, opts.Lower, opts.Upper)
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to filter variables for %s output: %s", output.Format, err), 1)
+	}
+	for _, name := range sortedReasonNames(filteredOut) {
+		core.PrintVerbose(fmt.Sprintf("\n\tFiltered out %s from %s output: %s", name, output.Format, filteredOut[name]))
+	}
+
+	outFileMode := output.Mode
+	if outFileMode == "" {
+		outFileMode = viper.GetString(core.OptStr_FileMode)
+	}
+
+	writeOutput(outputVariables, output.Format, output.Path, outFileMode, opts, watching)
+}
+
+// Format the variables, and write them to a file, or to STDOUT when no path is given.
+func writeOutput(variables map[string]*variable.Variable, format string, outFilePath string, outFileMode string, opts variable.FormatOptions, watching bool) {
+	formattedOutput, err := variable.Format(variables, format, opts)
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to format variables as %s: %s", format, err), 1)
+	}
+
+	// Export to later steps, and mask secrets in the GitHub Actions logs.
+	if format == variable.Format_Github {
+		if outFilePath == "" {
+			outFilePath = os.Getenv("GITHUB_ENV")
+		}
+		if outFilePath == "" {
+			// Printing the values instead would put the secrets in the job log.
+			core.PrintFatal("the github format needs $GITHUB_ENV to be set, or an outfile", 1)
+		}
+		// The runner reads workflow commands from STDOUT, which only has
+		// messages, since the values go to a file.
+		fmt.Fprint(os.Stdout, variable.GithubMasks(variables))
+	}
+
+	if outFilePath != "" && watching {
+		// Replace the file, since appending on every change would repeat values.
+		writeOutFileAtomic(formattedOutput, outFilePath, outFileMode)
+		core.PrintNormal(fmt.Sprintf("Wrote parameters to file: %s\n", outFilePath))
+	} else if outFilePath != "" {
+		// Dump formatted results to file.
+		writeFormattedOutFile(formattedOutput, outFilePath, outFileMode, variable.IsAppendableFormat(format))
+		core.PrintNormal(fmt.Sprintf("Wrote parameters to file: %s\n", outFilePath))
+	} else {
+		// Display formatted results to STDOUT.
+		core.PrintAlways(formattedOutput)
+		core.PrintNormal("\n")
+	}
 }
 
 // Write fetched, formatted values to file.
-func writeFormattedOutFile(formattedOutput string, outFilePath string, outFileMode string) {
+//
+// Structured formats can't be appended to, so their files are replaced.
+func writeFormattedOutFile(formattedOutput string, outFilePath string, outFileMode string, appendToFile bool) {
 	outFilePath = filepath.Clean(outFilePath)
 
 	modeValue, _ := strconv.ParseUint(outFileMode, 8, 32)
 	fileMode := os.FileMode(modeValue)
 
 	// If the file doesn't exist, create it, or append to the file.
-	fh, err := os.OpenFile(outFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode) //#nosec
+	flags := os.O_APPEND | os.O_CREATE | os.O_WRONLY
+	if !appendToFile {
+		flags = os.O_TRUNC | os.O_CREATE | os.O_WRONLY
+	}
+	fh, err := os.OpenFile(outFilePath, flags, fileMode) //#nosec
 	if err != nil {
 		core.PrintFatal(err.Error(), 1)
 	}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Run a test again in a child process, with an extra environment variable
+// telling it to run the part that exits.
+func runTestProcess(t *testing.T, name string, env ...string) (string, string, int) {
+	cmd := exec.Command(os.Args[0], "-test.run=^"+name+"$")
+	cmd.Env = append(append(os.Environ(), "LABRADOR_TEST_CHILD=1"), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	exitCode := 0
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), exitCode
+}
+
+// Variables for the GitHub output tests, one of them secret.
+func githubTestVariables() map[string]*variable.Variable {
+	return map[string]*variable.Variable{
+		"DB_HOST":     {Key: "DB_HOST", Value: "db.internal", Metadata: map[string]string{"type": "String"}},
+		"DB_PASSWORD": {Key: "DB_PASSWORD", Value: "hunter2", Metadata: map[string]string{"type": "SecureString"}},
+	}
+}
+
+func TestWriteOutputGithub(t *testing.T) {
+	if os.Getenv("LABRADOR_TEST_CHILD") == "1" {
+		writeOutput(githubTestVariables(), variable.Format_Github, "", "0600", variable.FormatOptions{}, false)
+		return
+	}
+
+	githubEnv := filepath.Join(t.TempDir(), "github_env")
+	if err := os.WriteFile(githubEnv, []byte("EARLIER_STEP=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout, _, exitCode := runTestProcess(t, "TestWriteOutputGithub", "GITHUB_ENV="+githubEnv)
+	if exitCode != 0 {
+		t.Fatalf("exit code %d, want 0", exitCode)
+	}
+	if !strings.Contains(stdout, "::add-mask::hunter2\n") {
+		t.Errorf("STDOUT %q doesn't mask the secret", stdout)
+	}
+	if strings.Contains(stdout, "DB_PASSWORD=") {
+		t.Errorf("STDOUT %q has the formatted values", stdout)
+	}
+
+	content, err := os.ReadFile(githubEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "EARLIER_STEP=1\nDB_HOST=db.internal\nDB_PASSWORD=hunter2\n"
+	if string(content) != want {
+		t.Errorf("got $GITHUB_ENV %q, want %q", content, want)
+	}
+}
+
+func TestWriteOutputGithubWithoutFile(t *testing.T) {
+	if os.Getenv("LABRADOR_TEST_CHILD") == "1" {
+		writeOutput(githubTestVariables(), variable.Format_Github, "", "0600", variable.FormatOptions{}, false)
+		return
+	}
+
+	stdout, stderr, exitCode := runTestProcess(t, "TestWriteOutputGithubWithoutFile", "GITHUB_ENV=")
+	if exitCode != 1 {
+		t.Fatalf("exit code %d, want 1", exitCode)
+	}
+	if strings.Contains(stdout+stderr, "hunter2") {
+		t.Errorf("the secret value was printed: %q", stdout+stderr)
+	}
+	if !strings.Contains(stdout, "needs $GITHUB_ENV") {
+		t.Errorf("got %q, want an error about $GITHUB_ENV", stdout)
+	}
+}
+
+func TestWritesFormat(t *testing.T) {
+	t.Cleanup(func() {
+		viper.Set(core.OptStr_Format, nil)
+		viper.Set(core.OptStr_OutFile, nil)
+	})
+	viper.Set(core.OptStr_Format, variable.Format_Github)
+
+	if !writesFormat(nil, variable.Format_Github) {
+		t.Error("the default output isn't detected")
+	}
+
+	// Configured outputs replace the default output.
+	outputs := []core.Output{{Format: variable.Format_Env}}
+	if writesFormat(outputs, variable.Format_Github) {
+		t.Error("the replaced default output is detected")
+	}
+
+	viper.Set(core.OptStr_OutFile, "github.env")
+	if !writesFormat(outputs, variable.Format_Github) {
+		t.Error("the default output written next to outputs isn't detected")
+	}
+
+	viper.Set(core.OptStr_Format, variable.Format_Env)
+	outputs = append(outputs, core.Output{Format: variable.Format_Github})
+	if !writesFormat(outputs, variable.Format_Github) {
+		t.Error("a configured output isn't detected")
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Exit code when the requested variable wasn't fetched, so scripts can
+// tell it apart from other failures.
+const getExitCodeMissing = 3
+
+var getCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Fetch and print a single value",
+	Long: `Fetch and print a single value, exactly as stored.
+
+Exits with code 3 when no fetched variable has the given name. Only the
+value is printed to STDOUT, and messages go to STDERR.`,
+	Args: cobra.ExactArgs(1),
+	Run:  get,
+}
+
+// Initialize the get CLI subcommand
+func init() {
+	getCmd.Flags().BoolP("no-newline", "n", false, "Don't print a newline after the value")
+
+	rootCmd.AddCommand(getCmd)
+}
+
+// Top level logic for the get CLI subcommand
+func get(cmd *cobra.Command, args []string) {
+	// STDOUT only gets the value, so scripts can capture it.
+	core.SetMessageOutput(os.Stderr)
+	// get implies --quiet
+	viper.Set(core.OptStr_Quiet, true)
+
+	if countRemoteTargets() == 0 {
+		core.PrintFatal("no remote values to fetch were specified", 1)
+	}
+
+	noNewline, _ := cmd.Flags().GetBool("no-newline")
+	printValue(os.Stdout, fetchVariables(), args[0], noNewline)
+}
+
+// Print the value of a variable, or exit with getExitCodeMissing when it
+// wasn't fetched.
+func printValue(w io.Writer, variables map[string]*variable.Variable, name string, noNewline bool) {
+	item, found := variable.LookupVariable(variables, name, formatOptions())
+	if !found {
+		core.PrintFatal(fmt.Sprintf("variable %s was not found", name), getExitCodeMissing)
+	}
+
+	fmt.Fprint(w, item.Value)
+	if !noNewline {
+		fmt.Fprint(w, "\n")
+	}
+}
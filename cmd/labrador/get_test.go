@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+func TestPrintValue(t *testing.T) {
+	variables := map[string]*variable.Variable{
+		"DB_URL": {Key: "DB_URL", Value: "postgres://app@db/app?sslmode=require"},
+	}
+
+	var output bytes.Buffer
+	printValue(&output, variables, "DB_URL", false)
+	if got := output.String(); got != "postgres://app@db/app?sslmode=require\n" {
+		t.Errorf("got %q, want the value and a newline", got)
+	}
+
+	output.Reset()
+	printValue(&output, variables, "DB_URL", true)
+	if got := output.String(); got != "postgres://app@db/app?sslmode=require" {
+		t.Errorf("got %q, want only the value", got)
+	}
+}
+
+// A missing variable exits with its own code, and leaves STDOUT empty so a
+// script capturing the value doesn't mistake the error for it.
+func TestPrintValueMissing(t *testing.T) {
+	if os.Getenv("LABRADOR_TEST_CHILD") == "1" {
+		core.SetMessageOutput(os.Stderr)
+		printValue(os.Stdout, map[string]*variable.Variable{}, "MISSING", false)
+		return
+	}
+
+	stdout, stderr, exitCode := runTestProcess(t, "TestPrintValueMissing")
+	if exitCode != getExitCodeMissing {
+		t.Fatalf("exit code %d, want %d", exitCode, getExitCodeMissing)
+	}
+	if stdout != "" {
+		t.Errorf("got STDOUT %q, want it empty", stdout)
+	}
+	if !strings.Contains(stderr, "variable MISSING was not found") {
+		t.Errorf("got STDERR %q, want the error", stderr)
+	}
+}
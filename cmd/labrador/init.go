@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/aws"
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a new config file",
+	Long: `Create a new, commented config file.
+
+When run in a terminal, asks for the region, paths and secrets to fetch,
+optionally discovering existing ones. Otherwise, or with --non-interactive,
+they are taken from --aws-region, --aws-param and --aws-secret, and
+--discover adds every existing path and secret below --prefix.`,
+	Args: cobra.NoArgs,
+	Run:  scaffoldConfig,
+}
+
+// Initialize the init CLI subcommand
+func init() {
+	initCmd.Flags().String("file", ".labrador.yaml", "Config file to create")
+	initCmd.Flags().Bool("force", false, "Replace the config file if it exists")
+	initCmd.Flags().Bool("non-interactive", false, "Don't ask any questions")
+	initCmd.Flags().Bool("discover", false, "Add existing SSM paths and secrets (non-interactive)")
+	initCmd.Flags().String("prefix", "", "Only discover SSM paths and secrets starting with this prefix")
+	initCmd.Flags().String("format", "env", "Output format for the config")
+	initCmd.Flags().String("outfile", "", "Output file for the config")
+
+	rootCmd.AddCommand(initCmd)
+}
+
+// Top level logic for the init CLI subcommand
+func scaffoldConfig(cmd *cobra.Command, args []string) {
+	ShowBanner()
+
+	path, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+	if _, err := os.Stat(path); err == nil && !force {
+		core.PrintFatal(fmt.Sprintf("%s already exists, use --force to replace it", path), 1)
+	}
+
+	scaffold := core.ConfigScaffold{
+		Region:    viper.GetString(core.OptStr_AWS_Region),
+		SsmParams: viper.GetStringSlice(core.OptStr_AWS_SsmParameterStore),
+		SmSecrets: viper.GetStringSlice(core.OptStr_AWS_SecretManager),
+	}
+	scaffold.Format, _ = cmd.Flags().GetString("format")
+	scaffold.OutFile, _ = cmd.Flags().GetString("outfile")
+	if scaffold.Region == "" {
+		scaffold.Region = defaultAwsRegion()
+	}
+
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	prefix, _ := cmd.Flags().GetString("prefix")
+	if nonInteractive || !isTerminal(os.Stdin) {
+		if discover, _ := cmd.Flags().GetBool("discover"); discover {
+			target := core.Target{Region: scaffold.Region}
+			scaffold.SsmParams = append(scaffold.SsmParams, discoverParameterPaths(target, prefix)...)
+			scaffold.SmSecrets = append(scaffold.SmSecrets, discoverSecrets(target, prefix)...)
+		}
+	} else {
+		scaffold = askConfigScaffold(bufio.NewReader(os.Stdin), scaffold, prefix)
+	}
+
+	content, err := scaffold.Render()
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	if err := os.WriteFile(filepath.Clean(path), content, 0644); err != nil { //#nosec
+		core.PrintFatal(err.Error(), 1)
+	}
+	core.PrintAlways(fmt.Sprintf("\nWrote config file: %s\n", path))
+}
+
+// Ask for the config settings, with the given ones as defaults.
+func askConfigScaffold(reader *bufio.Reader, scaffold core.ConfigScaffold, prefix string) core.ConfigScaffold {
+	core.PrintAlways("\n")
+	scaffold.Region = ask(reader, "AWS region", scaffold.Region)
+	target := core.Target{Region: scaffold.Region}
+
+	if confirm(reader, "Fetch from SSM Parameter Store?", true) {
+		candidates := make([]string, 0)
+		if confirm(reader, "  Discover existing parameter paths?", true) {
+			candidates = discoverParameterPaths(target, prefix)
+		}
+		scaffold.SsmParams = choose(reader, "  Paths to fetch", candidates, scaffold.SsmParams)
+	}
+
+	if confirm(reader, "Fetch from Secrets Manager?", len(scaffold.SmSecrets) != 0) {
+		candidates := make([]string, 0)
+		if confirm(reader, "  Discover existing secrets?", true) {
+			candidates = discoverSecrets(target, prefix)
+		}
+		scaffold.SmSecrets = choose(reader, "  Secrets to fetch", candidates, scaffold.SmSecrets)
+	}
+
+	scaffold.Format = ask(reader, "Output format", scaffold.Format)
+	scaffold.OutFile = ask(reader, "Output file (\"-\" for STDOUT)", scaffold.OutFile)
+	if scaffold.OutFile == "-" {
+		scaffold.OutFile = ""
+	}
+
+	return scaffold
+}
+
+// Discover SSM paths starting with a prefix, as one level wildcards.
+func discoverParameterPaths(target core.Target, prefix string) []string {
+	paths, truncated, err := aws.DiscoverParameterPaths(target, prefix)
+	if err != nil {
+		core.PrintWarning(fmt.Sprintf("can't discover SSM paths: %s", aws.ErrorSummary(err)))
+		return nil
+	}
+	if truncated {
+		core.PrintWarning("there are too many parameters to list them all, use --prefix to narrow them down")
+	}
+
+	wildcards := make([]string, 0, len(paths))
+	for _, discovered := range paths {
+		wildcards = append(wildcards, strings.TrimSuffix(discovered.Path, "/")+"/*")
+	}
+	return wildcards
+}
+
+// Discover secrets whose names start with a prefix.
+func discoverSecrets(target core.Target, prefix string) []string {
+	names, truncated, err := aws.DiscoverSecrets(target, prefix)
+	if err != nil {
+		core.PrintWarning(fmt.Sprintf("can't discover secrets: %s", aws.ErrorSummary(err)))
+		return nil
+	}
+	if truncated {
+		core.PrintWarning("there are too many secrets to list them all, use --prefix to narrow them down")
+	}
+
+	return names
+}
+
+// The region the AWS SDK would use from the environment, if any.
+func defaultAwsRegion() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+// Whether a file is an interactive terminal.
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Read one line of input.
+func readLine(reader *bufio.Reader) string {
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		core.PrintFatal(err.Error(), 1)
+	}
+	if err == io.EOF && line == "" {
+		core.PrintFatal("no input", 1)
+	}
+	return strings.TrimSpace(line)
+}
+
+// Ask a question, with a default answer for empty input.
+func ask(reader *bufio.Reader, question string, defaultAnswer string) string {
+	if defaultAnswer != "" {
+		core.PrintAlways(fmt.Sprintf("%s [%s]: ", question, defaultAnswer))
+	} else {
+		core.PrintAlways(fmt.Sprintf("%s: ", question))
+	}
+
+	if answer := readLine(reader); answer != "" {
+		return answer
+	}
+	return defaultAnswer
+}
+
+// Ask a yes/no question.
+func confirm(reader *bufio.Reader, question string, defaultYes bool) bool {
+	options := "y/N"
+	if defaultYes {
+		options = "Y/n"
+	}
+
+	for {
+		core.PrintAlways(fmt.Sprintf("%s [%s]: ", question, options))
+		switch strings.ToLower(readLine(reader)) {
+		case "":
+			return defaultYes
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+	}
+}
+
+// Ask for a list of items, picked by number from the candidates or typed
+// in, separated by commas.
+func choose(reader *bufio.Reader, question string, candidates []string, defaults []string) []string {
+	for i, candidate := range candidates {
+		core.PrintAlways(fmt.Sprintf("    %d) %s\n", i+1, candidate))
+	}
+
+	hint := "comma separated"
+	if len(candidates) != 0 {
+		hint = "numbers or values, comma separated"
+	}
+	answer := ask(reader, fmt.Sprintf("%s (%s)", question, hint), strings.Join(defaults, ","))
+
+	items := make([]string, 0)
+	for _, item := range strings.Split(answer, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(item); err == nil && n >= 1 && n <= len(candidates) {
+			item = candidates[n-1]
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List fetched variables and their sources, without values",
+	Long:  "List fetched variables and their sources, without values",
+	Run:   list,
+}
+
+// Initialize the list CLI subcommand
+func init() {
+	rootCmd.AddCommand(listCmd)
+}
+
+// Top level logic for the list CLI subcommand
+func list(cmd *cobra.Command, args []string) {
+	ShowBanner()
+
+	if countRemoteTargets() == 0 {
+		core.PrintFatal("no remote values to fetch were specified", 1)
+	}
+
+	variables := fetchVariables()
+
+	core.PrintDebug("\n")
+	core.PrintNormal(fmt.Sprintf("\nFetched %d values\n", len(variables)))
+	core.PrintDebug("\n")
+
+	table, err := variable.VariablesAsTable(variables, formatOptions())
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to list variables: %s", err), 1)
+	}
+
+	core.PrintAlways(table)
+	core.PrintAlways("\n")
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/aws"
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Write values from an env file to a service",
+	Long: `Write values from an env file to a service.
+
+The destination is a single --aws-param path, where each value becomes a
+parameter below it, or a single --aws-secret, holding all values as a JSON
+object. Existing values are only changed with --overwrite.
+
+The destination must be given as a flag. The paths and secrets of the config
+file are never written to, since they are meant to be fetched from.`,
+	Run: push,
+}
+
+// Initialize the push CLI subcommand
+func init() {
+
+	// from
+	defaultFrom := viper.GetViper().GetString(core.OptStr_PushFrom)
+	pushCmd.PersistentFlags().String("from", defaultFrom, "Env file to read values from")
+	err := viper.BindPFlag(core.OptStr_PushFrom, pushCmd.PersistentFlags().Lookup("from"))
+	if err != nil {
+		panic(err)
+	}
+
+	// dry-run
+	defaultDryRun := viper.GetViper().GetBool(core.OptStr_DryRun)
+	pushCmd.PersistentFlags().Bool("dry-run", defaultDryRun, "Show the planned changes without writing them")
+	err = viper.BindPFlag(core.OptStr_DryRun, pushCmd.PersistentFlags().Lookup("dry-run"))
+	if err != nil {
+		panic(err)
+	}
+
+	initWriteFlags(pushCmd)
+
+	rootCmd.AddCommand(pushCmd)
+}
+
+// Add the flags controlling how values are written, shared by push and sync.
+//
+// A viper key can only be bound to one flag, so they're bound by bindWriteFlags
+// when the command runs.
+func initWriteFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("overwrite", false, "Update existing values that differ, instead of only creating new ones")
+	cmd.PersistentFlags().String("kms-key-id", "", "KMS key for new SecureString parameters and secrets")
+	cmd.PersistentFlags().String("param-type", "", "Type of new SSM parameters (String, SecureString)")
+	cmd.PersistentFlags().StringSlice("tag", nil, "Tag for written parameters and secrets (key=value)")
+}
+
+// Bind the write flags of the running command to their viper keys.
+func bindWriteFlags(cmd *cobra.Command) {
+	flagKeys := map[string]string{
+		"overwrite":  core.OptStr_Overwrite,
+		"kms-key-id": core.OptStr_KmsKeyId,
+		"param-type": core.OptStr_ParameterType,
+		"tag":        core.OptStr_Tags,
+	}
+	for flag, key := range flagKeys {
+		if err := viper.BindPFlag(key, cmd.PersistentFlags().Lookup(flag)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Top level logic for the push CLI subcommand
+func push(cmd *cobra.Command, args []string) {
+	bindWriteFlags(cmd)
+	ShowBanner()
+
+	from := viper.GetString(core.OptStr_PushFrom)
+	if from == "" {
+		core.PrintFatal("an env file to push is required (--from)", 1)
+	}
+
+	kind, target, err := pushDestination(cmd)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	store, err := aws.NewStore(kind, target, writeOptions())
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	values := readEnvFileValues(from)
+	current, changes := planValues(store, values, viper.GetBool(core.OptStr_Overwrite), false)
+	if len(changes) == 0 {
+		return
+	}
+	if viper.GetBool(core.OptStr_DryRun) {
+		core.PrintAlways("Dry run, no changes were written\n")
+		return
+	}
+	applyValues(store, current, changes)
+}
+
+// The kind of store and target that push writes to.
+//
+// Only the --aws-param and --aws-secret flags are used, so a config file
+// listing a single path to fetch from is never written to by accident.
+func pushDestination(cmd *cobra.Command) (string, core.Target, error) {
+	paramSet := cmd.Flags().Changed("aws-param")
+	secretSet := cmd.Flags().Changed("aws-secret")
+	if paramSet == secretSet {
+		return "", core.Target{}, fmt.Errorf("push needs exactly one destination flag (--aws-param or --aws-secret)")
+	}
+
+	kind, flag := aws.Store_SsmParameterStore, "aws-param"
+	if secretSet {
+		kind, flag = aws.Store_SecretsManager, "aws-secret"
+	}
+	resources, err := cmd.Flags().GetStringSlice(flag)
+	if err != nil {
+		return "", core.Target{}, err
+	}
+	if len(resources) != 1 {
+		return "", core.Target{}, fmt.Errorf("push needs exactly one destination, got %d --%s values", len(resources), flag)
+	}
+
+	return kind, core.Target{Resource: resources[0]}, nil
+}
+
+// Collect the configured write options.
+func writeOptions() aws.WriteOptions {
+	return aws.WriteOptions{
+		ParameterType: viper.GetString(core.OptStr_ParameterType),
+		KmsKeyId:      viper.GetString(core.OptStr_KmsKeyId),
+		Tags:          parseKeyValuePairs(core.OptStr_Tags),
+	}
+}
+
+// Plan the changes that make a store hold the given values, and show them.
+//
+// Existing values are only updated when overwrite is set, and values
+// missing from the given set are only deleted when prune is set. Returns
+// the current values of the store, and the planned changes.
+func planValues(store aws.Store, values map[string]string, overwrite bool, prune bool) (map[string]string, []variable.Difference) {
+	current, err := store.Read()
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	changes := make([]variable.Difference, 0)
+	skipped := make([]variable.Difference, 0)
+	for _, difference := range variable.DiffValues(values, current) {
+		switch {
+		case difference.Change == variable.Change_Changed && !overwrite:
+			skipped = append(skipped, difference)
+		case difference.Change == variable.Change_Removed && !prune:
+			continue
+		default:
+			changes = append(changes, difference)
+		}
+	}
+
+	core.PrintAlways(formatPlan(store, changes, skipped))
+
+	return current, changes
+}
+
+// Apply planned changes to a store.
+func applyValues(store aws.Store, current map[string]string, changes []variable.Difference) {
+	if err := store.Apply(current, changes); err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	core.PrintNormal("\n")
+	core.PrintAlways(fmt.Sprintf("Wrote %d changes to %s\n", len(changes), store))
+}
+
+// Format a plan of changes to a store. Values are never shown.
+func formatPlan(store aws.Store, changes []variable.Difference, skipped []variable.Difference) string {
+	result := fmt.Sprintf("\nPlan for %s:\n", store)
+
+	counts := make(map[string]int, 0)
+	for _, change := range changes {
+		counts[change.Change]++
+		switch change.Change {
+		case variable.Change_Added:
+			result += fmt.Sprintf("  + create %s\n", change.Name)
+		case variable.Change_Changed:
+			result += fmt.Sprintf("  ~ update %s\n", change.Name)
+		case variable.Change_Removed:
+			result += fmt.Sprintf("  - delete %s\n", change.Name)
+		}
+	}
+	for _, change := range skipped {
+		result += fmt.Sprintf("  ! skip %s (exists with a different value, use --overwrite)\n", change.Name)
+	}
+
+	if len(changes) == 0 {
+		return result + "No changes\n"
+	}
+
+	return result + fmt.Sprintf("%d to create, %d to update, %d to delete\n",
+		counts[variable.Change_Added], counts[variable.Change_Changed], counts[variable.Change_Removed])
+}
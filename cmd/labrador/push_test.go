@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/divergentcodes/labrador/internal/aws"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// A store holding values in memory.
+type memoryStore struct {
+	values map[string]string
+}
+
+func (store *memoryStore) String() string {
+	return "memory"
+}
+
+func (store *memoryStore) Read() (map[string]string, error) {
+	return store.values, nil
+}
+
+func (store *memoryStore) Apply(current map[string]string, changes []variable.Difference) error {
+	for _, change := range changes {
+		if change.Change == variable.Change_Removed {
+			delete(store.values, change.Name)
+		} else {
+			store.values[change.Name] = change.New
+		}
+	}
+	return nil
+}
+
+func TestPlanValues(t *testing.T) {
+	values := map[string]string{"NEW": "1", "SAME": "2", "CHANGED": "3"}
+
+	tests := []struct {
+		name      string
+		overwrite bool
+		prune     bool
+		want      []string
+	}{
+		{name: "create only", want: []string{"NEW"}},
+		{name: "overwrite", overwrite: true, want: []string{"CHANGED", "NEW"}},
+		{name: "prune", prune: true, want: []string{"NEW", "OLD"}},
+		{name: "overwrite and prune", overwrite: true, prune: true, want: []string{"CHANGED", "NEW", "OLD"}},
+	}
+	for _, test := range tests {
+		store := &memoryStore{values: map[string]string{"SAME": "2", "CHANGED": "old", "OLD": "4"}}
+		_, changes := planValues(store, values, test.overwrite, test.prune)
+
+		names := make([]string, 0, len(changes))
+		for _, change := range changes {
+			names = append(names, change.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("%s: planned %v, want %v", test.name, names, test.want)
+		}
+	}
+}
+
+func TestApplyValues(t *testing.T) {
+	store := &memoryStore{values: map[string]string{"SAME": "2", "CHANGED": "old", "OLD": "4"}}
+	values := map[string]string{"NEW": "1", "SAME": "2", "CHANGED": "3"}
+
+	current, changes := planValues(store, values, true, true)
+	applyValues(store, current, changes)
+
+	if !reflect.DeepEqual(store.values, values) {
+		t.Errorf("store holds %v, want %v", store.values, values)
+	}
+}
+
+func TestFormatPlan(t *testing.T) {
+	store := &memoryStore{}
+	changes := []variable.Difference{
+		{Name: "NEW", Change: variable.Change_Added, New: "secret-new"},
+		{Name: "CHANGED", Change: variable.Change_Changed, Old: "secret-old", New: "secret-changed"},
+		{Name: "GONE", Change: variable.Change_Removed, Old: "secret-gone"},
+	}
+	skipped := []variable.Difference{
+		{Name: "KEPT", Change: variable.Change_Changed, Old: "secret-kept", New: "secret-other"},
+	}
+
+	want := "\nPlan for memory:\n" +
+		"  + create NEW\n" +
+		"  ~ update CHANGED\n" +
+		"  - delete GONE\n" +
+		"  ! skip KEPT (exists with a different value, use --overwrite)\n" +
+		"1 to create, 1 to update, 1 to delete\n"
+	if got := formatPlan(store, changes, skipped); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := formatPlan(store, nil, nil); got != "\nPlan for memory:\nNo changes\n" {
+		t.Errorf("empty plan is %q", got)
+	}
+}
+
+func TestPushDestination(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    map[string]string
+		kind     string
+		resource string
+	}{
+		{name: "path", flags: map[string]string{"aws-param": "/app/dev/"}, kind: aws.Store_SsmParameterStore, resource: "/app/dev/"},
+		{name: "secret", flags: map[string]string{"aws-secret": "app/dev"}, kind: aws.Store_SecretsManager, resource: "app/dev"},
+		// Config file targets have the same defaults as the flags, but
+		// aren't given on the command line.
+		{name: "none"},
+		{name: "both", flags: map[string]string{"aws-param": "/app/dev/", "aws-secret": "app/dev"}},
+		{name: "two paths", flags: map[string]string{"aws-param": "/app/dev/,/app/prod/"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().StringSlice("aws-param", []string{"/from/config/*"}, "")
+			cmd.Flags().StringSlice("aws-secret", nil, "")
+			for flag, value := range test.flags {
+				if err := cmd.Flags().Set(flag, value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			kind, target, err := pushDestination(cmd)
+			if test.kind == "" {
+				if err == nil {
+					t.Errorf("got destination %s %s, want an error", kind, target.Resource)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kind != test.kind || target.Resource != test.resource {
+				t.Errorf("got destination %s %s, want %s %s", kind, target.Resource, test.kind, test.resource)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render",
+	Short: "Fetch values and render them into a template",
+	Long: `Fetch values and render them into a Go text/template.
+
+Variables are referenced by name, like {{ .DB_HOST.Value }}, and also have
+.Source and .Metadata fields. Helpers: b64enc, b64dec, json, required, default.`,
+	Run: render,
+}
+
+// Initialize the render CLI subcommand
+func init() {
+
+	// template
+	defaultTemplate := viper.GetViper().GetString(core.OptStr_Template)
+	renderCmd.PersistentFlags().StringP("template", "t", defaultTemplate, "Template file to render")
+	err := viper.BindPFlag(core.OptStr_Template, renderCmd.PersistentFlags().Lookup("template"))
+	if err != nil {
+		panic(err)
+	}
+
+	// outfile
+	defaultOutFile := viper.GetViper().GetString(core.OptStr_RenderOutFile)
+	renderCmd.PersistentFlags().StringP("outfile", "o", defaultOutFile, "File path to write the rendered template to")
+	err = viper.BindPFlag(core.OptStr_RenderOutFile, renderCmd.PersistentFlags().Lookup("outfile"))
+	if err != nil {
+		panic(err)
+	}
+
+	// outfile-mode
+	defaultFileMode := viper.GetViper().GetString(core.OptStr_RenderFileMode)
+	renderCmd.PersistentFlags().String("outfile-mode", defaultFileMode, "File permissions for newly created outfile")
+	err = viper.BindPFlag(core.OptStr_RenderFileMode, renderCmd.PersistentFlags().Lookup("outfile-mode"))
+	if err != nil {
+		panic(err)
+	}
+
+	rootCmd.AddCommand(renderCmd)
+}
+
+// Top level logic for the render CLI subcommand
+func render(cmd *cobra.Command, args []string) {
+	ShowBanner()
+
+	templatePath := viper.GetString(core.OptStr_Template)
+	if templatePath == "" {
+		core.PrintFatal("a template file is required (--template)", 1)
+	}
+
+	// Read the template before fetching, to fail fast on a bad path.
+	templateText, err := os.ReadFile(filepath.Clean(templatePath))
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to read template: %s", err), 1)
+	}
+
+	if countRemoteTargets() == 0 {
+		core.PrintFatal("no remote values to fetch were specified", 1)
+	}
+
+	variables := fetchVariables()
+
+	core.PrintDebug("\n")
+	core.PrintNormal(fmt.Sprintf("\nFetched %d values\n", len(variables)))
+	core.PrintDebug("\n")
+
+	rendered, err := variable.RenderTemplate(filepath.Base(templatePath), string(templateText), variables, formatOptions())
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to render template: %s", err), 1)
+	}
+
+	outFilePath := viper.GetString(core.OptStr_RenderOutFile)
+	outFileMode := viper.GetString(core.OptStr_RenderFileMode)
+	if outFilePath != "" {
+		writeFormattedOutFile(rendered, outFilePath, outFileMode, false)
+		core.PrintNormal(fmt.Sprintf("Wrote rendered template to file: %s\n", outFilePath))
+	} else {
+		core.PrintAlways(rendered)
+	}
+}
@@ -16,24 +16,40 @@ Usage:
 Available Commands:
 
 	completion  Generate the autocompletion script for the specified shell
+	diff        Compare fetched values against an env file or another config
+	doctor      Diagnose the config, credentials and access to each target
 	export      Fetch and export values as shell environment variables
 	fetch       Fetch values from services
+	get         Fetch and print a single value
 	help        Help about any command
+	init        Create a new config file
+	list        List fetched variables and their sources, without values
+	push        Write values from an env file to a service
+	render      Fetch values and render them into a template
+	run         Run a command with fetched values as environment variables
+	sync        Copy values from one service or environment to another
+	validate    Check the config file and required variables
 	version     Print the version
 
 Flags:
 
-	    --aws-param strings    AWS SSM parameter store path prefix
-	    --aws-region string    AWS region
-	    --aws-secret strings   AWS Secrets Manager secret name
-	-c, --config string        config file (default is .labrador.yaml)
-	    --debug                Enable debug mode
-	-h, --help                 help for labrador
-	    --lower                Set all variable names to lower case
-	-q, --quiet                Quiet CLI output
-	    --quote                Surround each value with doublequotes
-	    --upper                Set all variable names to upper case
-	    --verbose              Verbose CLI output
+	    --aws-param strings         AWS SSM parameter store path prefix
+	    --aws-param-naming string   AWS SSM parameter naming mode (leaf, relative)
+	    --aws-region string         AWS region
+	    --aws-secret strings        AWS Secrets Manager secret name
+	-c, --config string             config file (default is .labrador.yaml)
+	    --debug                     Enable debug mode
+	    --exclude strings           Drop variables whose name, path or ARN match a glob or re: pattern
+	-h, --help                      help for labrador
+	    --include strings           Only keep variables whose name, path or ARN match a glob or re: pattern
+	    --invalid-names string      Policy for invalid variable names (sanitize, skip, error)
+	    --lower                     Set all variable names to lower case
+	    --name-replacement string   Replacement for characters not allowed in variable names
+	-q, --quiet                     Quiet CLI output
+	    --quote                     Surround each value with doublequotes
+	    --sort string               Output order of variables (alpha, source)
+	    --upper                     Set all variable names to upper case
+	    --verbose                   Verbose CLI output
 
 Use "labrador [command] --help" for more information about a command.
 */
@@ -41,6 +57,7 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -151,6 +168,14 @@ func initRootFlags() {
 		panic(err)
 	}
 
+	// aws-param-naming
+	defaultAwsSsmNaming := viper.GetViper().GetString(core.OptStr_AWS_SsmNaming)
+	rootCmd.PersistentFlags().String("aws-param-naming", defaultAwsSsmNaming, "AWS SSM parameter naming mode (leaf, relative)")
+	err = viper.BindPFlag(core.OptStr_AWS_SsmNaming, rootCmd.PersistentFlags().Lookup("aws-param-naming"))
+	if err != nil {
+		panic(err)
+	}
+
 	// aws-secret
 	defaultAwsSmSecrets := viper.GetViper().GetStringSlice(core.OptStr_AWS_SecretManager)
 	rootCmd.PersistentFlags().StringSlice("aws-secret", defaultAwsSmSecrets, "AWS Secrets Manager secret name")
@@ -159,6 +184,48 @@ func initRootFlags() {
 		panic(err)
 	}
 
+	// name-replacement
+	defaultNameReplacement := viper.GetViper().GetString(core.OptStr_NameReplacement)
+	rootCmd.PersistentFlags().String("name-replacement", defaultNameReplacement, "Replacement for characters not allowed in variable names")
+	err = viper.BindPFlag(core.OptStr_NameReplacement, rootCmd.PersistentFlags().Lookup("name-replacement"))
+	if err != nil {
+		panic(err)
+	}
+
+	// invalid-names
+	defaultInvalidNames := viper.GetViper().GetString(core.OptStr_InvalidNames)
+	rootCmd.PersistentFlags().String("invalid-names", defaultInvalidNames, "Policy for invalid variable names (sanitize, skip, error)")
+	err = viper.BindPFlag(core.OptStr_InvalidNames, rootCmd.PersistentFlags().Lookup("invalid-names"))
+	if err != nil {
+		panic(err)
+	}
+
+	// sort
+	defaultSort := viper.GetViper().GetString(core.OptStr_Sort)
+	rootCmd.PersistentFlags().String("sort", defaultSort, "Output order of variables (alpha, source)")
+	err = viper.BindPFlag(core.OptStr_Sort, rootCmd.PersistentFlags().Lookup("sort"))
+	if err != nil {
+		panic(err)
+	}
+
+	// Filters.
+
+	// include
+	defaultInclude := viper.GetViper().GetStringSlice(core.OptStr_Include)
+	rootCmd.PersistentFlags().StringSlice("include", defaultInclude, "Only keep variables whose name, path or ARN match a glob or re: pattern")
+	err = viper.BindPFlag(core.OptStr_Include, rootCmd.PersistentFlags().Lookup("include"))
+	if err != nil {
+		panic(err)
+	}
+
+	// exclude
+	defaultExclude := viper.GetViper().GetStringSlice(core.OptStr_Exclude)
+	rootCmd.PersistentFlags().StringSlice("exclude", defaultExclude, "Drop variables whose name, path or ARN match a glob or re: pattern")
+	err = viper.BindPFlag(core.OptStr_Exclude, rootCmd.PersistentFlags().Lookup("exclude"))
+	if err != nil {
+		panic(err)
+	}
+
 	rootCmd.MarkFlagsMutuallyExclusive("lower", "upper")
 	rootCmd.MarkFlagsMutuallyExclusive("quiet", "debug")
 	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
@@ -189,25 +256,134 @@ func bannerText() string {
 
 // Count the number of user-defined resources to pull values from.
 func countRemoteTargets() int {
-	remoteTargetCount := 0
+	return countConfigTargets(viper.GetViper())
+}
 
-	awsSsmParameters := viper.GetStringSlice(core.OptStr_AWS_SsmParameterStore)
-	remoteTargetCount += len(awsSsmParameters)
+// Count the number of resources to pull values from in a viper instance.
+func countConfigTargets(v *viper.Viper) int {
+	remoteTargetCount := 0
 
-	awsSmSecrets := viper.GetStringSlice(core.OptStr_AWS_SecretManager)
-	remoteTargetCount += len(awsSmSecrets)
+	for _, key := range []string{core.OptStr_AWS_SsmParameterStore, core.OptStr_AWS_SecretManager} {
+		targets, err := core.GetTargetsFrom(v, key)
+		if err != nil {
+			core.PrintFatal(err.Error(), 1)
+		}
+		remoteTargetCount += len(targets)
+	}
 
 	return remoteTargetCount
 }
 
+// Fetch values from all configured remote services, and apply the global
+// rename rules, name sanitization and filters.
+func fetchVariables() map[string]*variable.Variable {
+	variables, err := tryFetchVariables()
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	return variables
+}
+
+// Like fetchVariables, but returning errors instead of exiting, for
+// commands that keep running after a failed fetch.
+func tryFetchVariables() (map[string]*variable.Variable, error) {
+	return tryFetchConfigVariables(viper.GetViper())
+}
+
+// Like tryFetchVariables, with the targets and settings of a viper instance.
+func tryFetchConfigVariables(v *viper.Viper) (map[string]*variable.Variable, error) {
+
+	variables := make(map[string]*variable.Variable, 0)
+	variables, err := fetchAwsSsmParameters(v, variables)
+	if err != nil {
+		return nil, err
+	}
+	variables, err = fetchAwsSmSecrets(v, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	renameRules, err := core.GetRenameRulesFrom(v)
+	if err != nil {
+		return nil, err
+	}
+	variables, err = variable.RenameVariables(variables, renameRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename variables: %w", err)
+	}
+
+	replacement := v.GetString(core.OptStr_NameReplacement)
+	invalidNames := v.GetString(core.OptStr_InvalidNames)
+	lower := v.GetBool(core.OptStr_ToLower)
+	upper := v.GetBool(core.OptStr_ToUpper)
+	variables, skipped, err := variable.SanitizeVariableNames(variables, replacement, invalidNames, lower, upper)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range sortedReasonNames(skipped) {
+		core.PrintWarning(fmt.Sprintf("skipping variable %q: %s", name, skipped[name]))
+	}
+
+	// Filters see the names as they are written in output.
+	variables, filteredOut, err := variable.FilterVariables(variables, core.GetKeyFilterFrom(v), lower, upper)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter variables: %w", err)
+	}
+	for _, name := range sortedReasonNames(filteredOut) {
+		core.PrintVerbose(fmt.Sprintf("\n\tFiltered out %s: %s", name, filteredOut[name]))
+	}
+
+	return variables, nil
+}
+
+// Names of the variables in a map of reasons they were dropped, sorted so
+// messages about them come out in a stable order.
+func sortedReasonNames(reasons map[string]string) []string {
+	names := make([]string, 0, len(reasons))
+	for name := range reasons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Collect the configured output formatting options.
+func formatOptions() variable.FormatOptions {
+	return variable.FormatOptions{
+		Quote:     viper.GetBool(core.OptStr_Quote),
+		Lower:     viper.GetBool(core.OptStr_ToLower),
+		Upper:     viper.GetBool(core.OptStr_ToUpper),
+		Dialect:   viper.GetString(core.OptStr_Dialect),
+		Shell:     viper.GetString(core.OptStr_Shell),
+		Sort:      viper.GetString(core.OptStr_Sort),
+		Nest:      viper.GetBool(core.OptStr_Nest),
+		Separator: viper.GetString(core.OptStr_Separator),
+		Kubernetes: variable.KubernetesOptions{
+			Name:        viper.GetString(core.OptStr_K8sName),
+			Namespace:   viper.GetString(core.OptStr_K8sNamespace),
+			Labels:      parseKeyValuePairs(core.OptStr_K8sLabels),
+			Annotations: parseKeyValuePairs(core.OptStr_K8sAnnotations),
+		},
+	}
+}
+
+// Parse a configured list of "key=value" strings into a map.
+func parseKeyValuePairs(key string) map[string]string {
+	pairs, err := core.ParseKeyValuePairs(viper.GetStringSlice(key))
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("invalid %s: %s", key, err), 1)
+	}
+	return pairs
+}
+
 // Fetch AWS SSM Parameter Store values, convert to variables, add to list, and return the list.
-func fetchAwsSsmParameters(variables map[string]*variable.Variable) map[string]*variable.Variable {
+func fetchAwsSsmParameters(v *viper.Viper, variables map[string]*variable.Variable) (map[string]*variable.Variable, error) {
 
-	awsSsmParameters := viper.GetStringSlice(core.OptStr_AWS_SsmParameterStore)
+	awsSsmParameters := v.GetStringSlice(core.OptStr_AWS_SsmParameterStore)
 	if len(awsSsmParameters) != 0 {
-		ssmVariables, err := aws.FetchParameterStore()
+		ssmVariables, err := aws.FetchParameterStore(v)
 		if err != nil {
-			core.PrintFatal("failed to get SSM parameters", 1)
+			return nil, fmt.Errorf("failed to get SSM parameters: %w", err)
 		}
 
 		core.PrintVerbose(fmt.Sprintf("\nFetched %d values from AWS SSM Parameter Store", len(ssmVariables)))
@@ -220,17 +396,17 @@ func fetchAwsSsmParameters(variables map[string]*variable.Variable) map[string]*
 		}
 	}
 
-	return variables
+	return variables, nil
 }
 
 // Fetch AWS Secrets Manager values, convert to variables, add to list, and return the list.
-func fetchAwsSmSecrets(variables map[string]*variable.Variable) map[string]*variable.Variable {
+func fetchAwsSmSecrets(v *viper.Viper, variables map[string]*variable.Variable) (map[string]*variable.Variable, error) {
 
-	awsSmSecrets := viper.GetStringSlice(core.OptStr_AWS_SecretManager)
+	awsSmSecrets := v.GetStringSlice(core.OptStr_AWS_SecretManager)
 	if len(awsSmSecrets) != 0 {
-		smVariables, err := aws.FetchSecretsManager()
+		smVariables, err := aws.FetchSecretsManager(v)
 		if err != nil {
-			core.PrintFatal("failed to get Secrets Manager values", 1)
+			return nil, fmt.Errorf("failed to get Secrets Manager values: %w", err)
 		}
 
 		core.PrintVerbose(fmt.Sprintf("\nFetched %d values from AWS Secrets Manager", len(smVariables)))
@@ -244,5 +420,5 @@ func fetchAwsSmSecrets(variables map[string]*variable.Variable) map[string]*vari
 		}
 	}
 
-	return variables
+	return variables, nil
 }
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [flags] -- command [args...]",
+	Short: "Run a command with fetched values as environment variables",
+	Long: `Run a command with fetched values as environment variables.
+
+The command's exit code is passed through, and signals sent to labrador are
+forwarded to it. For example:
+
+	labrador run --aws-param "/app/*" -- ./server --port 8080
+
+With --restart-on-change, values are fetched again every --interval while the
+command runs. When the version of a value changes, the command is stopped
+with SIGTERM, killed after --kill-timeout if it's still running, and started
+again with the new values. With --signal, it's sent that signal instead, and
+keeps running with its current environment.`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runCommand,
+}
+
+// Initialize the run CLI subcommand
+func init() {
+
+	// Everything after the command belongs to the command.
+	runCmd.Flags().SetInterspersed(false)
+
+	// restart-on-change
+	defaultRestartOnChange := viper.GetViper().GetBool(core.OptStr_RestartOnChange)
+	runCmd.PersistentFlags().Bool("restart-on-change", defaultRestartOnChange, "Keep fetching values, and restart or signal the command when they change")
+	err := viper.BindPFlag(core.OptStr_RestartOnChange, runCmd.PersistentFlags().Lookup("restart-on-change"))
+	if err != nil {
+		panic(err)
+	}
+
+	// kill-timeout
+	defaultKillTimeout := viper.GetViper().GetString(core.OptStr_KillTimeout)
+	runCmd.PersistentFlags().String("kill-timeout", defaultKillTimeout, "Time to wait for the command to stop before killing it on restart")
+	err = viper.BindPFlag(core.OptStr_KillTimeout, runCmd.PersistentFlags().Lookup("kill-timeout"))
+	if err != nil {
+		panic(err)
+	}
+
+	// signal
+	defaultSignal := viper.GetViper().GetString(core.OptStr_RunSignal)
+	runCmd.PersistentFlags().String("signal", defaultSignal, "Signal to send to the command when values change, instead of restarting it")
+	err = viper.BindPFlag(core.OptStr_RunSignal, runCmd.PersistentFlags().Lookup("signal"))
+	if err != nil {
+		panic(err)
+	}
+
+	initWatchFlags(runCmd)
+
+	rootCmd.AddCommand(runCmd)
+}
+
+// Top level logic for the run CLI subcommand
+func runCommand(cmd *cobra.Command, args []string) {
+	bindWatchFlags(cmd)
+
+	// STDOUT belongs to the command.
+	core.SetMessageOutput(os.Stderr)
+	ShowBanner()
+
+	if countRemoteTargets() == 0 {
+		core.PrintFatal("no remote values to fetch were specified", 1)
+	}
+
+	// Check the watch settings before the first fetch.
+	restartOnChange := viper.GetBool(core.OptStr_RestartOnChange)
+	if !restartOnChange {
+		for _, flag := range []string{"interval", "kill-timeout", "signal"} {
+			if cmd.Flags().Changed(flag) {
+				core.PrintFatal(fmt.Sprintf("--%s needs --restart-on-change", flag), 1)
+			}
+		}
+	}
+	var interval, killTimeout time.Duration
+	var reloadSignal syscall.Signal
+	if restartOnChange {
+		var err error
+		interval, err = watchInterval()
+		if err != nil {
+			core.PrintFatal(err.Error(), 1)
+		}
+
+		setting := viper.GetString(core.OptStr_KillTimeout)
+		killTimeout, err = time.ParseDuration(setting)
+		if err != nil {
+			core.PrintFatal(fmt.Sprintf("invalid kill timeout %q", setting), 1)
+		}
+
+		if name := viper.GetString(core.OptStr_RunSignal); name != "" {
+			reloadSignal, err = parseSignal(name)
+			if err != nil {
+				core.PrintFatal(err.Error(), 1)
+			}
+		}
+	}
+
+	variables := fetchVariables()
+
+	core.PrintNormal(fmt.Sprintf("\nFetched %d values\n", len(variables)))
+	core.PrintNormal("\n")
+
+	signals := make(chan os.Signal, 1)
+	for _, sig := range signalNames {
+		signal.Notify(signals, sig)
+	}
+
+	child, exited := startChild(args, variables)
+
+	var ticks <-chan time.Time
+	versions := variable.VariableVersions(variables)
+	if restartOnChange {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case sig := <-signals:
+			// The command decides whether to stop, and its exit ends the loop.
+			if err := child.Process.Signal(sig); err != nil {
+				core.PrintVerbose(fmt.Sprintf("\nFailed to forward %s: %s", sig, err))
+			}
+
+		case err := <-exited:
+			signal.Stop(signals)
+			os.Exit(childExitCode(err))
+
+		case <-ticks:
+			current, changed := fetchChanges(versions, interval)
+			if len(changed) == 0 {
+				continue
+			}
+			versions = variable.VariableVersions(current)
+
+			if reloadSignal != 0 {
+				core.PrintNormal(fmt.Sprintf("Sending %s to %s\n", reloadSignal, args[0]))
+				if err := child.Process.Signal(reloadSignal); err != nil {
+					core.PrintWarning(fmt.Sprintf("failed to signal %s: %s", args[0], err))
+				}
+				continue
+			}
+
+			core.PrintNormal(fmt.Sprintf("Restarting %s\n", args[0]))
+			interrupted, err := stopChild(child, exited, killTimeout, signals)
+			if interrupted {
+				// Labrador was asked to stop while the command was stopping.
+				signal.Stop(signals)
+				os.Exit(childExitCode(err))
+			}
+			child, exited = startChild(args, current)
+		}
+	}
+}
+
+// Start a command with the variables added to its environment.
+//
+// The returned channel receives the result of waiting for the command.
+func startChild(args []string, variables map[string]*variable.Variable) (*exec.Cmd, <-chan error) {
+	child := exec.Command(args[0], args[1:]...) //#nosec
+	child.Env = childEnvironment(variables)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+
+	if err := child.Start(); err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to start %s: %s", args[0], err), 1)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+
+	return child, exited
+}
+
+// The environment of labrador, with fetched values added or replacing
+// existing ones.
+func childEnvironment(variables map[string]*variable.Variable) []string {
+	values := variable.VariableValues(variables, formatOptions())
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Later entries win over duplicate names.
+	environment := os.Environ()
+	for _, name := range names {
+		environment = append(environment, name+"="+values[name])
+	}
+	return environment
+}
+
+// Stop a command gracefully with SIGTERM, or kill it after a timeout.
+//
+// Signals received meanwhile are forwarded. Returns whether one of them
+// asked labrador itself to stop, so the command isn't restarted, and the
+// result of waiting for the command.
+func stopChild(child *exec.Cmd, exited <-chan error, killTimeout time.Duration, signals <-chan os.Signal) (bool, error) {
+	interrupted := false
+
+	if err := child.Process.Signal(syscall.SIGTERM); err != nil {
+		// Already exited, or can't be signaled, like on Windows.
+		_ = child.Process.Kill()
+	}
+
+	timer := time.NewTimer(killTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case err := <-exited:
+			return interrupted, err
+		case sig := <-signals:
+			if sig == os.Interrupt || sig == syscall.SIGTERM {
+				interrupted = true
+			}
+			_ = child.Process.Signal(sig)
+		case <-timer.C:
+			core.PrintWarning(fmt.Sprintf("%s didn't stop within %s, killing it", child.Path, killTimeout))
+			if err := child.Process.Kill(); err != nil {
+				core.PrintWarning(fmt.Sprintf("failed to kill %s: %s", child.Path, err))
+			}
+		}
+	}
+}
+
+// The exit code to pass through from a command, like a shell does:
+// 128 plus the signal number when it was killed by a signal.
+func childExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		core.PrintWarning(err.Error())
+		return 1
+	}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
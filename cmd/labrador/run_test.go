@@ -0,0 +1,71 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+func TestChildExitCode(t *testing.T) {
+	variables := map[string]*variable.Variable{}
+
+	_, exited := startChild([]string{"sh", "-c", "exit 3"}, variables)
+	if code := childExitCode(<-exited); code != 3 {
+		t.Errorf("exit code %d, want 3", code)
+	}
+
+	child, exited := startChild([]string{"sleep", "5"}, variables)
+	if err := child.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	if code := childExitCode(<-exited); code != 128+int(syscall.SIGTERM) {
+		t.Errorf("exit code %d, want %d", code, 128+int(syscall.SIGTERM))
+	}
+}
+
+func TestChildEnvironment(t *testing.T) {
+	t.Setenv("LABRADOR_TEST_VALUE", "old")
+	variables := map[string]*variable.Variable{
+		"LABRADOR_TEST_VALUE": {Key: "LABRADOR_TEST_VALUE", Value: "new value"},
+	}
+
+	_, exited := startChild([]string{"sh", "-c", `test "$LABRADOR_TEST_VALUE" = "new value"`}, variables)
+	if err := <-exited; err != nil {
+		t.Errorf("fetched value didn't replace the existing one: %s", err)
+	}
+}
+
+func TestStopChild(t *testing.T) {
+	variables := map[string]*variable.Variable{}
+	signals := make(chan os.Signal, 1)
+
+	// Stops gracefully.
+	child, exited := startChild([]string{"sleep", "5"}, variables)
+	interrupted, _ := stopChild(child, exited, time.Second, signals)
+	if interrupted {
+		t.Error("stopChild reported an interrupt")
+	}
+
+	// Killed when it ignores SIGTERM.
+	child, exited = startChild([]string{"sh", "-c", "trap '' TERM; while :; do sleep 0.05; done"}, variables)
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	stopChild(child, exited, 200*time.Millisecond, signals)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stopChild took %s, want about 200ms", elapsed)
+	}
+
+	// An interrupt while stopping is reported.
+	child, exited = startChild([]string{"sh", "-c", "trap 'sleep 0.2; exit 0' TERM; while :; do sleep 0.05; done"}, variables)
+	time.Sleep(100 * time.Millisecond)
+	signals <- os.Interrupt
+	interrupted, _ = stopChild(child, exited, time.Second, signals)
+	if !interrupted {
+		t.Error("stopChild didn't report the interrupt")
+	}
+}
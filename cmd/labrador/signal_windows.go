@@ -0,0 +1,9 @@
+//go:build windows
+
+package cmd
+
+import "syscall"
+
+// Windows processes can only be killed, not signaled, so no signals can be
+// sent when values change, and --signal and --signal-pid are rejected.
+var signalNames = map[string]syscall.Signal{}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/aws"
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Copy values from one service or environment to another",
+	Long: `Copy values from one service or environment to another.
+
+Stores are given as store:resource, where store is ssm (the parameters
+directly below a path) or secretsmanager (the key/value pairs in a JSON
+secret). For example:
+
+	labrador sync --from ssm:/app/prod --to secretsmanager:app/prod
+
+The planned creates, updates and deletes are shown, and only written with
+--apply. Existing values are only updated with --overwrite, and values only
+in the destination are only deleted with --prune.`,
+	Run: sync,
+}
+
+// Initialize the sync CLI subcommand
+func init() {
+
+	// from
+	syncCmd.PersistentFlags().String("from", "", "Store to copy values from (store:resource)")
+	err := viper.BindPFlag(core.OptStr_SyncFrom, syncCmd.PersistentFlags().Lookup("from"))
+	if err != nil {
+		panic(err)
+	}
+
+	// to
+	syncCmd.PersistentFlags().String("to", "", "Store to copy values to (store:resource)")
+	err = viper.BindPFlag(core.OptStr_SyncTo, syncCmd.PersistentFlags().Lookup("to"))
+	if err != nil {
+		panic(err)
+	}
+
+	// prune
+	defaultPrune := viper.GetViper().GetBool(core.OptStr_SyncPrune)
+	syncCmd.PersistentFlags().Bool("prune", defaultPrune, "Delete values in the destination that aren't in the source")
+	err = viper.BindPFlag(core.OptStr_SyncPrune, syncCmd.PersistentFlags().Lookup("prune"))
+	if err != nil {
+		panic(err)
+	}
+
+	// apply
+	defaultApply := viper.GetViper().GetBool(core.OptStr_SyncApply)
+	syncCmd.PersistentFlags().Bool("apply", defaultApply, "Write the planned changes, instead of only showing them")
+	err = viper.BindPFlag(core.OptStr_SyncApply, syncCmd.PersistentFlags().Lookup("apply"))
+	if err != nil {
+		panic(err)
+	}
+
+	initWriteFlags(syncCmd)
+
+	rootCmd.AddCommand(syncCmd)
+}
+
+// Top level logic for the sync CLI subcommand
+func sync(cmd *cobra.Command, args []string) {
+	bindWriteFlags(cmd)
+	ShowBanner()
+
+	from := syncStore(core.OptStr_SyncFrom)
+	to := syncStore(core.OptStr_SyncTo)
+	if from.String() == to.String() {
+		core.PrintFatal(fmt.Sprintf("can't sync %s to itself", from), 1)
+	}
+
+	values, err := from.Read()
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	// An empty source is more likely a mistake than a request to delete everything.
+	if len(values) == 0 {
+		core.PrintFatal(fmt.Sprintf("no values found in %s", from), 1)
+	}
+
+	core.PrintNormal(fmt.Sprintf("\nRead %d values from %s\n", len(values), from))
+	current, changes := planValues(to, values, viper.GetBool(core.OptStr_Overwrite), viper.GetBool(core.OptStr_SyncPrune))
+	if len(changes) == 0 {
+		return
+	}
+	if !viper.GetBool(core.OptStr_SyncApply) {
+		core.PrintAlways("Plan only, no changes were written. Run again with --apply to write them.\n")
+		return
+	}
+	applyValues(to, current, changes)
+}
+
+// Create the store configured at a viper key.
+func syncStore(key string) aws.Store {
+	kind, target, err := core.GetStoreTarget(key)
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	store, err := aws.NewStore(kind, target, writeOptions())
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	return store
+}
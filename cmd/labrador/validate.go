@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file and required variables",
+	Long: `Check the config file and required variables.
+
+The config file is checked for unknown settings and invalid values. When
+the config declares required variables, values are fetched to check that
+each one is present and matches its pattern, unless --offline is given.`,
+	Args: cobra.NoArgs,
+	Run:  validate,
+}
+
+// Initialize the validate CLI subcommand
+func init() {
+	validateCmd.Flags().Bool("offline", false, "Only check the config file, without fetching required variables")
+
+	rootCmd.AddCommand(validateCmd)
+}
+
+// Top level logic for the validate CLI subcommand
+func validate(cmd *cobra.Command, args []string) {
+	ShowBanner()
+
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		core.PrintFatal("no config file found", 1)
+	}
+	core.PrintNormal(fmt.Sprintf("\nValidating %s\n", configFile))
+
+	problems := validateConfigFile(configFile)
+
+	// Settings are only checked further once the file itself is valid.
+	if len(problems) == 0 {
+		problems = append(problems, validateSettings()...)
+	}
+
+	offline, _ := cmd.Flags().GetBool("offline")
+	if len(problems) == 0 && !offline {
+		problems = append(problems, validateRequiredVariables()...)
+	}
+
+	if len(problems) == 0 {
+		core.PrintAlways("Config is valid\n")
+		return
+	}
+
+	for _, problem := range problems {
+		core.PrintAlways(fmt.Sprintf("  %s\n", problem))
+	}
+	if len(problems) == 1 {
+		core.PrintFatal("found 1 problem", 1)
+	}
+	core.PrintFatal(fmt.Sprintf("found %d problems", len(problems)), 1)
+}
+
+// Check the config file against the schema.
+func validateConfigFile(configFile string) []string {
+	content, err := os.ReadFile(filepath.Clean(configFile))
+	if err != nil {
+		core.PrintFatal(fmt.Sprintf("failed to read %s: %s", configFile, err), 1)
+	}
+
+	configProblems, err := core.ValidateConfig(content)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid YAML: %s", err)}
+	}
+
+	problems := make([]string, 0, len(configProblems))
+	for _, problem := range configProblems {
+		problems = append(problems, problem.String())
+	}
+	return problems
+}
+
+// Check that settings built from several values can be parsed, including
+// those set by flags and environment variables.
+func validateSettings() []string {
+	problems := make([]string, 0)
+	report := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, key := range []string{core.OptStr_AWS_SsmParameterStore, core.OptStr_AWS_SecretManager} {
+		_, err := core.GetTargets(key)
+		report(err)
+	}
+	_, err := core.GetOutputs(core.OptStr_Outputs)
+	report(err)
+	_, err = core.GetRenameRules()
+	report(err)
+	_, err = core.GetRequiredVariables()
+	report(err)
+
+	return problems
+}
+
+// Fetch values, and check that every required variable is present and valid.
+func validateRequiredVariables() []string {
+	required, err := core.GetRequiredVariables()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if len(required) == 0 {
+		return nil
+	}
+
+	if countRemoteTargets() == 0 {
+		return []string{"required variables are declared, but no remote values to fetch were specified"}
+	}
+
+	variables := fetchVariables()
+	core.PrintNormal(fmt.Sprintf("\nFetched %d values\n", len(variables)))
+
+	problems, err := variable.CheckRequiredVariables(variables, required, formatOptions())
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return problems
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Shortest allowed interval between fetches, to stay clear of AWS API throttling.
+const minWatchInterval = time.Second
+
+// Parse a signal name, like "HUP" or "SIGHUP".
+//
+// The supported signals depend on the platform, see signalNames.
+func parseSignal(name string) (syscall.Signal, error) {
+	if len(signalNames) == 0 {
+		return 0, fmt.Errorf("sending signals isn't supported on %s", runtime.GOOS)
+	}
+	sig, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal %q, expected one of %s", name, signalNameList())
+	}
+	return sig, nil
+}
+
+// Add the watch interval flag shared by fetch and run.
+//
+// Like the write flags, it's bound by bindWatchFlags when the command runs.
+func initWatchFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().String("interval", "", "Time between fetches when watching (default 5m)")
+}
+
+// Bind the watch interval flag of the running command to its viper key.
+func bindWatchFlags(cmd *cobra.Command) {
+	if err := viper.BindPFlag(core.OptStr_WatchInterval, cmd.PersistentFlags().Lookup("interval")); err != nil {
+		panic(err)
+	}
+}
+
+// The configured interval between fetches.
+func watchInterval() (time.Duration, error) {
+	setting := viper.GetString(core.OptStr_WatchInterval)
+	interval, err := time.ParseDuration(setting)
+	if err != nil {
+		return 0, fmt.Errorf("invalid watch interval %q", setting)
+	}
+	if interval < minWatchInterval {
+		return 0, fmt.Errorf("watch interval %s is shorter than %s", interval, minWatchInterval)
+	}
+	return interval, nil
+}
+
+// Re-fetch values every interval, until interrupted.
+//
+// onChange is called with the new values and the names that changed,
+// whenever fetchChanges finds any.
+func watchVariables(variables map[string]*variable.Variable, interval time.Duration, onChange func(map[string]*variable.Variable, []string)) {
+	versions := variable.VariableVersions(variables)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	core.PrintNormal(fmt.Sprintf("\nWatching for changes every %s\n", interval))
+	for {
+		select {
+		case <-stop:
+			core.PrintNormal("\nStopped watching\n")
+			return
+		case <-ticker.C:
+		}
+
+		current, changed := fetchChanges(versions, interval)
+		if len(changed) != 0 {
+			onChange(current, changed)
+			versions = variable.VariableVersions(current)
+		}
+	}
+}
+
+// Fetch values again, and compare their versions to the previous ones.
+//
+// Returns the new values and the names whose version changed, or that were
+// added or removed. Failed fetches are reported, and count as no change
+// until they are retried after the interval.
+func fetchChanges(versions map[string]string, interval time.Duration) (map[string]*variable.Variable, []string) {
+	current, err := tryFetchVariables()
+	if err != nil {
+		core.PrintWarning(fmt.Sprintf("failed to fetch values, retrying in %s: %s", interval, err))
+		return nil, nil
+	}
+
+	differences := variable.DiffValues(variable.VariableVersions(current), versions)
+	if len(differences) == 0 {
+		core.PrintVerbose(fmt.Sprintf("\n%s: no changes", time.Now().Format(time.RFC3339)))
+		return current, nil
+	}
+
+	changed := make([]string, 0, len(differences))
+	for _, difference := range differences {
+		changed = append(changed, difference.Name)
+	}
+	diff, _ := variable.FormatDiff(differences, variable.DiffValues_Redacted)
+	core.PrintNormal(fmt.Sprintf("\n%s: %d value(s) changed\n%s\n", time.Now().Format(time.RFC3339), len(changed), diff))
+
+	return current, changed
+}
+
+// Run the change hook command with a shell, if any.
+//
+// The names of the changed values are passed in LABRADOR_CHANGED, separated by commas.
+func runChangeHook(changed []string) {
+	command := viper.GetString(core.OptStr_OnChange)
+	if command == "" {
+		return
+	}
+
+	hook := exec.Command("sh", "-c", command) //#nosec
+	hook.Stdin = os.Stdin
+	hook.Stdout = os.Stdout
+	hook.Stderr = os.Stderr
+	hook.Env = append(os.Environ(), "LABRADOR_CHANGED="+strings.Join(changed, ","))
+
+	core.PrintVerbose(fmt.Sprintf("\nRunning change hook: %s", command))
+	if err := hook.Run(); err != nil {
+		core.PrintWarning(fmt.Sprintf("change hook failed: %s", err))
+	}
+}
+
+// Send the configured signal to the configured process, if any.
+func signalChangedProcess() {
+	pid := viper.GetInt(core.OptStr_SignalPid)
+	if pid == 0 {
+		return
+	}
+
+	sig, err := configuredSignal()
+	if err != nil {
+		core.PrintWarning(err.Error())
+		return
+	}
+
+	core.PrintVerbose(fmt.Sprintf("\nSending %s to process %d", sig, pid))
+	process, err := os.FindProcess(pid)
+	if err == nil {
+		err = process.Signal(sig)
+	}
+	if err != nil {
+		core.PrintWarning(fmt.Sprintf("failed to signal process %d: %s", pid, err))
+	}
+}
+
+// The configured signal to send on change, SIGHUP by default.
+func configuredSignal() (syscall.Signal, error) {
+	name := viper.GetString(core.OptStr_Signal)
+	if name == "" {
+		name = "HUP"
+	}
+	return parseSignal(name)
+}
+
+// Supported signal names, for help and error messages.
+func signalNameList() string {
+	names := make([]string, 0, len(signalNames))
+	for name := range signalNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Replace a file with new content atomically, so readers never see a
+// partially written file.
+//
+// The content is written to a temporary file in the same directory, which
+// is then renamed over the file. An existing file keeps its permissions.
+func writeOutFileAtomic(formattedOutput string, outFilePath string, outFileMode string) {
+	outFilePath = filepath.Clean(outFilePath)
+
+	modeValue, _ := strconv.ParseUint(outFileMode, 8, 32)
+	fileMode := os.FileMode(modeValue)
+	if info, err := os.Stat(outFilePath); err == nil {
+		fileMode = info.Mode().Perm()
+	}
+
+	fh, err := os.CreateTemp(filepath.Dir(outFilePath), "."+filepath.Base(outFilePath)+".*.tmp")
+	if err != nil {
+		core.PrintFatal(err.Error(), 1)
+	}
+	tempPath := fh.Name()
+	fail := func(err error) {
+		fh.Close()
+		os.Remove(tempPath)
+		core.PrintFatal(err.Error(), 1)
+	}
+
+	if err := fh.Chmod(fileMode); err != nil {
+		fail(err)
+	}
+	if _, err := fh.WriteString(formattedOutput); err != nil {
+		fail(err)
+	}
+	if err := fh.Sync(); err != nil {
+		fail(err)
+	}
+	if err := fh.Close(); err != nil {
+		fail(err)
+	}
+	if err := os.Rename(tempPath, outFilePath); err != nil {
+		os.Remove(tempPath)
+		core.PrintFatal(err.Error(), 1)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestParseSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		if _, err := parseSignal("HUP"); err == nil {
+			t.Error("parseSignal(HUP) succeeded on Windows")
+		}
+		return
+	}
+
+	for _, name := range []string{"HUP", "hup", "SIGHUP", "sighup"} {
+		sig, err := parseSignal(name)
+		if err != nil {
+			t.Errorf("parseSignal(%q) failed: %s", name, err)
+		} else if sig != signalNames["HUP"] {
+			t.Errorf("parseSignal(%q) = %s, want SIGHUP", name, sig)
+		}
+	}
+	for _, name := range []string{"", "KILL", "SIGFOO", "1"} {
+		if _, err := parseSignal(name); err == nil {
+			t.Errorf("parseSignal(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestWriteOutFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+
+	writeOutFileAtomic("A=1\n", path, "0640")
+	assertFile(t, path, "A=1\n")
+	if runtime.GOOS != "windows" {
+		assertMode(t, path, 0640)
+	}
+
+	// Existing files keep their permissions.
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeOutFileAtomic("A=2\n", path, "0640")
+	assertFile(t, path, "A=2\n")
+	if runtime.GOOS != "windows" {
+		assertMode(t, path, 0600)
+	}
+
+	// No temporary files are left behind.
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("found %d files, want 1", len(entries))
+	}
+}
+
+func TestRunChangeHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("change hooks run with sh")
+	}
+
+	path := filepath.Join(t.TempDir(), "changed")
+	viper.Set(core.OptStr_OnChange, `printf %s "$LABRADOR_CHANGED" > "`+path+`"`)
+	t.Cleanup(func() { viper.Set(core.OptStr_OnChange, "") })
+
+	runChangeHook([]string{"DB_HOST", "DB_PASSWORD"})
+	assertFile(t, path, "DB_HOST,DB_PASSWORD")
+}
+
+func assertFile(t *testing.T, path string, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Errorf("%s contains %q, want %q", path, content, want)
+	}
+}
+
+func assertMode(t *testing.T, path string, want os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != want {
+		t.Errorf("%s has mode %o, want %o", path, info.Mode().Perm(), want)
+	}
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+// Load the AWS SDK configuration for a target.
+//
+// The target's region and profile take precedence over the global region
+// and the standard AWS environment variables.
+func loadAwsConfig(target core.Target) (aws.Config, error) {
+	awsRegion := targetRegion(target)
+
+	// Using the SDK's default configuration, loading additional config
+	// and credentials values from the environment variables, shared
+	// credentials, and shared configuration files
+	options := make([]func(*config.LoadOptions) error, 0)
+	if target.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(target.Profile))
+		core.PrintDebug(fmt.Sprintf("\nSet AWS profile: %s", target.Profile))
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(context.TODO(), options...)
+	if err != nil {
+		return awsConfig, fmt.Errorf("unable to load AWS SDK config, %w", err)
+	}
+	if awsRegion != "" {
+		awsConfig.Region = awsRegion
+		core.PrintDebug(fmt.Sprintf("\nSet AWS region: %s", awsRegion))
+	}
+
+	return awsConfig, nil
+}
+
+// The shared config profile that a loaded AWS SDK configuration uses.
+//
+// A target's own profile wins over AWS_PROFILE, which wins over the
+// default profile.
+func resolvedProfile(awsConfig aws.Config, target core.Target) string {
+	if target.Profile != "" {
+		return target.Profile
+	}
+	for _, source := range awsConfig.ConfigSources {
+		switch source := source.(type) {
+		case config.EnvConfig:
+			if source.SharedConfigProfile != "" {
+				return source.SharedConfigProfile
+			}
+		case config.SharedConfig:
+			if source.Profile != "" {
+				return source.Profile
+			}
+		}
+	}
+	return config.DefaultSharedConfigProfile
+}
+
+// Targets configured at a key of a viper instance. Targets without their
+// own region get the instance's region, if it has one.
+func configuredTargets(v *viper.Viper, key string) ([]core.Target, error) {
+	targets, err := core.GetTargetsFrom(v, key)
+	if err != nil {
+		return nil, err
+	}
+	for i := range targets {
+		if targets[i].Region == "" {
+			targets[i].Region = v.GetString(core.OptStr_AWS_Region)
+		}
+	}
+	return targets, nil
+}
+
+// The explicitly configured region for a target, if any.
+func targetRegion(target core.Target) string {
+	if target.Region != "" {
+		return target.Region
+	}
+	return viper.GetString(core.OptStr_AWS_Region)
+}
+
+// Key identifying the region/credential set used by a target.
+func clientKey(target core.Target) string {
+	return targetRegion(target) + "|" + target.Profile
+}
+
+// Describe a target's own region and profile, if it sets them, for messages.
+func targetLocation(target core.Target) string {
+	switch {
+	case target.Region != "" && target.Profile != "":
+		return fmt.Sprintf(" (region %s, profile %s)", target.Region, target.Profile)
+	case target.Region != "":
+		return fmt.Sprintf(" (region %s)", target.Region)
+	case target.Profile != "":
+		return fmt.Sprintf(" (profile %s)", target.Profile)
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestClientKey(t *testing.T) {
+	viper.Set(core.OptStr_AWS_Region, "us-east-1")
+	t.Cleanup(func() { viper.Set(core.OptStr_AWS_Region, nil) })
+
+	// Explicit parameters sharing a client key are fetched in one batch.
+	same := []core.Target{
+		{Resource: "/a"},
+		{Resource: "/b", Region: "us-east-1"},
+	}
+	if clientKey(same[0]) != clientKey(same[1]) {
+		t.Errorf("%q and %q should share a client", clientKey(same[0]), clientKey(same[1]))
+	}
+
+	different := []core.Target{
+		{Resource: "/a"},
+		{Resource: "/a", Region: "eu-west-1"},
+		{Resource: "/a", Profile: "other"},
+	}
+	keys := make(map[string]bool, 0)
+	for _, target := range different {
+		keys[clientKey(target)] = true
+	}
+	if len(keys) != len(different) {
+		t.Errorf("got client keys %v, want %d different ones", keys, len(different))
+	}
+}
+
+func TestTargetLocation(t *testing.T) {
+	tests := map[string]core.Target{
+		"":                                   {},
+		" (region eu-west-1)":                {Region: "eu-west-1"},
+		" (profile other)":                   {Profile: "other"},
+		" (region eu-west-1, profile other)": {Region: "eu-west-1", Profile: "other"},
+	}
+	for want, target := range tests {
+		if got := targetLocation(target); got != want {
+			t.Errorf("targetLocation(%+v) = %q, want %q", target, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smTypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+// Maximum number of pages to list when discovering, so accounts with many
+// thousands of parameters or secrets don't stall.
+const discoverMaxPages = 50
+
+// DiscoveredPath is a SSM path holding parameters directly below it.
+type DiscoveredPath struct {
+	Path  string
+	Count int
+}
+
+// DiscoverParameterPaths lists the paths that hold SSM parameters whose
+// names start with a prefix, using only parameter metadata, sorted by path.
+//
+// Parameters without a leading slash aren't below any path, so they are
+// left out. Also returns whether listing stopped before the last page.
+func DiscoverParameterPaths(target core.Target, prefix string) ([]DiscoveredPath, bool, error) {
+	awsConfig, err := loadAwsConfig(target)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return discoverParameterPaths(ssm.NewFromConfig(awsConfig), prefix)
+}
+
+// List the SSM parameter paths with a client.
+func discoverParameterPaths(ssmClient ssm.DescribeParametersAPIClient, prefix string) ([]DiscoveredPath, bool, error) {
+	input := &ssm.DescribeParametersInput{
+		MaxResults: aws.Int32(50),
+	}
+	if prefix != "" {
+		input.ParameterFilters = []ssmTypes.ParameterStringFilter{{
+			Key:    aws.String("Name"),
+			Option: aws.String("BeginsWith"),
+			Values: []string{prefix},
+		}}
+	}
+
+	counts := make(map[string]int, 0)
+	paginator := ssm.NewDescribeParametersPaginator(ssmClient, input)
+	for page := 0; paginator.HasMorePages() && page < discoverMaxPages; page++ {
+		resp, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list SSM parameters, %w", err)
+		}
+		for _, parameter := range resp.Parameters {
+			name := aws.ToString(parameter.Name)
+			if !strings.HasPrefix(name, "/") {
+				continue
+			}
+			counts[path.Dir(name)]++
+		}
+	}
+
+	paths := make([]DiscoveredPath, 0, len(counts))
+	for dir, count := range counts {
+		paths = append(paths, DiscoveredPath{Path: dir, Count: count})
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].Path < paths[j].Path
+	})
+
+	return paths, paginator.HasMorePages(), nil
+}
+
+// DiscoverSecrets lists the names of Secrets Manager secrets that start
+// with a prefix, sorted.
+//
+// Also returns whether listing stopped before the last page.
+func DiscoverSecrets(target core.Target, prefix string) ([]string, bool, error) {
+	awsConfig, err := loadAwsConfig(target)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return discoverSecrets(secretsmanager.NewFromConfig(awsConfig), prefix)
+}
+
+// List the Secrets Manager secret names with a client.
+func discoverSecrets(smClient secretsmanager.ListSecretsAPIClient, prefix string) ([]string, bool, error) {
+	input := &secretsmanager.ListSecretsInput{
+		MaxResults: aws.Int32(100),
+	}
+	if prefix != "" {
+		// The name filter matches prefixes, ignoring case.
+		input.Filters = []smTypes.Filter{{
+			Key:    smTypes.FilterNameStringTypeName,
+			Values: []string{prefix},
+		}}
+	}
+
+	names := make([]string, 0)
+	paginator := secretsmanager.NewListSecretsPaginator(smClient, input)
+	for page := 0; paginator.HasMorePages() && page < discoverMaxPages; page++ {
+		resp, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list AWS Secrets Manager secrets, %w", err)
+		}
+		for _, secret := range resp.SecretList {
+			names = append(names, aws.ToString(secret.Name))
+		}
+	}
+	sort.Strings(names)
+
+	return names, paginator.HasMorePages(), nil
+}
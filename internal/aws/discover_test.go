@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smTypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// Fake listing client, returning one page of names per call.
+type fakeLister struct {
+	pages  [][]string
+	inputs []interface{}
+}
+
+// The names of the page a token points at, and the token of the next page.
+func (c *fakeLister) page(token *string) ([]string, *string) {
+	index := 0
+	if token != nil {
+		index, _ = strconv.Atoi(*token)
+	}
+	if index+1 < len(c.pages) {
+		return c.pages[index], aws.String(strconv.Itoa(index + 1))
+	}
+	return c.pages[index], nil
+}
+
+func (c *fakeLister) DescribeParameters(ctx context.Context, params *ssm.DescribeParametersInput, optFns ...func(*ssm.Options)) (*ssm.DescribeParametersOutput, error) {
+	c.inputs = append(c.inputs, params)
+	names, next := c.page(params.NextToken)
+	output := &ssm.DescribeParametersOutput{NextToken: next}
+	for _, name := range names {
+		output.Parameters = append(output.Parameters, ssmTypes.ParameterMetadata{Name: aws.String(name)})
+	}
+	return output, nil
+}
+
+func (c *fakeLister) ListSecrets(ctx context.Context, params *secretsmanager.ListSecretsInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.ListSecretsOutput, error) {
+	c.inputs = append(c.inputs, params)
+	names, next := c.page(params.NextToken)
+	output := &secretsmanager.ListSecretsOutput{NextToken: next}
+	for _, name := range names {
+		output.SecretList = append(output.SecretList, smTypes.SecretListEntry{Name: aws.String(name)})
+	}
+	return output, nil
+}
+
+func TestDiscoverParameterPaths(t *testing.T) {
+	client := &fakeLister{pages: [][]string{
+		{"/app/dev/DB_HOST", "/app/dev/DB_USER", "DB_HOST"},
+		{"/app/prod/DB_HOST", "/ROOT_VALUE", "legacy.value"},
+	}}
+
+	paths, truncated, err := discoverParameterPaths(client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DiscoveredPath{{Path: "/", Count: 1}, {Path: "/app/dev", Count: 2}, {Path: "/app/prod", Count: 1}}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %+v, want %+v", paths, want)
+	}
+	if truncated {
+		t.Error("listing was reported as truncated")
+	}
+	if input := client.inputs[0].(*ssm.DescribeParametersInput); len(input.ParameterFilters) != 0 {
+		t.Errorf("got filters %+v without a prefix", input.ParameterFilters)
+	}
+}
+
+func TestDiscoverParameterPathsPrefix(t *testing.T) {
+	client := &fakeLister{pages: [][]string{{"/app/dev/DB_HOST"}}}
+
+	if _, _, err := discoverParameterPaths(client, "/app/dev"); err != nil {
+		t.Fatal(err)
+	}
+	want := []ssmTypes.ParameterStringFilter{{
+		Key:    aws.String("Name"),
+		Option: aws.String("BeginsWith"),
+		Values: []string{"/app/dev"},
+	}}
+	if input := client.inputs[0].(*ssm.DescribeParametersInput); !reflect.DeepEqual(input.ParameterFilters, want) {
+		t.Errorf("got filters %+v, want a Name BeginsWith filter", input.ParameterFilters)
+	}
+}
+
+func TestDiscoverParameterPathsTruncated(t *testing.T) {
+	client := &fakeLister{}
+	for i := 0; i <= discoverMaxPages; i++ {
+		client.pages = append(client.pages, []string{fmt.Sprintf("/app/p%d/VALUE", i)})
+	}
+
+	paths, truncated, err := discoverParameterPaths(client, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !truncated {
+		t.Error("listing wasn't reported as truncated")
+	}
+	if len(client.inputs) != discoverMaxPages || len(paths) != discoverMaxPages {
+		t.Errorf("got %d calls and %d paths, want %d", len(client.inputs), len(paths), discoverMaxPages)
+	}
+}
+
+func TestDiscoverSecrets(t *testing.T) {
+	client := &fakeLister{pages: [][]string{{"app/dev/db", "app/dev/api"}, {"app/dev/cache"}}}
+
+	names, truncated, err := discoverSecrets(client, "app/dev/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"app/dev/api", "app/dev/cache", "app/dev/db"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %v, want %v", names, want)
+	}
+	if truncated {
+		t.Error("listing was reported as truncated")
+	}
+
+	want := []smTypes.Filter{{Key: smTypes.FilterNameStringTypeName, Values: []string{"app/dev/"}}}
+	for _, input := range client.inputs {
+		if filters := input.(*secretsmanager.ListSecretsInput).Filters; !reflect.DeepEqual(filters, want) {
+			t.Errorf("got filters %+v, want a name filter", filters)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+// Identity is the AWS identity used for a region and profile.
+type Identity struct {
+	Region  string
+	Profile string
+	Account string
+	Arn     string
+	Error   error
+}
+
+// TargetProbe is the result of checking access to a single target.
+type TargetProbe struct {
+	Store    string
+	Resource string
+	// Region the target resolves to, after the SDK's own lookup.
+	Region  string
+	Profile string
+	Error   error
+}
+
+// The STS call doctor makes, so it can be replaced in tests.
+type callerIdentityAPI interface {
+	GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error)
+}
+
+// The SSM calls that probe parameters.
+type ssmProbeAPI interface {
+	ssmGetParametersAPI
+	ssm.GetParametersByPathAPIClient
+}
+
+// The Secrets Manager call that probes secrets.
+type secretValueAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// Constructors of the clients doctor uses, for each loaded configuration.
+type doctorClients struct {
+	sts            func(aws.Config) callerIdentityAPI
+	ssm            func(aws.Config) ssmProbeAPI
+	secretsManager func(aws.Config) secretValueAPI
+}
+
+// Clients calling AWS.
+var awsDoctorClients = doctorClients{
+	sts:            func(awsConfig aws.Config) callerIdentityAPI { return sts.NewFromConfig(awsConfig) },
+	ssm:            func(awsConfig aws.Config) ssmProbeAPI { return ssm.NewFromConfig(awsConfig) },
+	secretsManager: func(awsConfig aws.Config) secretValueAPI { return secretsmanager.NewFromConfig(awsConfig) },
+}
+
+// GetIdentities looks up the caller identity of each region and profile
+// the targets use, in the order they are first used.
+func GetIdentities(targets []core.Target) []Identity {
+	return getIdentities(targets, awsDoctorClients)
+}
+
+// Look up caller identities with a set of clients.
+func getIdentities(targets []core.Target, clients doctorClients) []Identity {
+	identities := make([]Identity, 0)
+	seen := make(map[string]bool, 0)
+
+	for _, target := range targets {
+		key := clientKey(target)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		identity := Identity{Profile: target.Profile}
+		awsConfig, err := loadAwsConfig(target)
+		if err != nil {
+			identity.Error = err
+			identities = append(identities, identity)
+			continue
+		}
+		identity.Region = awsConfig.Region
+		identity.Profile = resolvedProfile(awsConfig, target)
+
+		resp, err := clients.sts(awsConfig).GetCallerIdentity(context.TODO(), &sts.GetCallerIdentityInput{})
+		if err != nil {
+			identity.Error = fmt.Errorf("failed to get caller identity, %w", err)
+		} else {
+			identity.Account = aws.ToString(resp.Account)
+			identity.Arn = aws.ToString(resp.Arn)
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities
+}
+
+// ProbeTarget checks that a target can be read, the same way fetch reads it.
+//
+// Values are fetched and decrypted, to check KMS access too, but never returned.
+func ProbeTarget(store string, target core.Target) TargetProbe {
+	return probeTarget(store, target, awsDoctorClients)
+}
+
+// Probe a target with a set of clients.
+func probeTarget(store string, target core.Target, clients doctorClients) TargetProbe {
+	probe := TargetProbe{Store: store, Resource: target.Resource, Profile: target.Profile}
+
+	awsConfig, err := loadAwsConfig(target)
+	if err != nil {
+		probe.Error = err
+		return probe
+	}
+	probe.Region = awsConfig.Region
+	probe.Profile = resolvedProfile(awsConfig, target)
+	if probe.Region == "" {
+		probe.Error = fmt.Errorf("no region is set")
+		return probe
+	}
+
+	switch store {
+	case Store_SsmParameterStore:
+		probe.Error = probeParameterStore(clients.ssm(awsConfig), target.Resource)
+	case Store_SecretsManager:
+		input := &secretsmanager.GetSecretValueInput{SecretId: aws.String(target.Resource)}
+		if _, err := clients.secretsManager(awsConfig).GetSecretValue(context.TODO(), input); err != nil {
+			probe.Error = err
+		}
+	default:
+		probe.Error = fmt.Errorf("unsupported store %q", store)
+	}
+
+	return probe
+}
+
+// Read one parameter at a wildcard path, or the named parameter.
+func probeParameterStore(ssmClient ssmProbeAPI, resource string) error {
+	root, recursive, ok := parseWildcardPath(resource)
+	if ok {
+		input := &ssm.GetParametersByPathInput{
+			Path:           aws.String(root),
+			Recursive:      aws.Bool(recursive),
+			WithDecryption: aws.Bool(true),
+			MaxResults:     aws.Int32(1),
+		}
+		_, err := ssmClient.GetParametersByPath(context.TODO(), input)
+		return err
+	}
+
+	_, invalid, err := getParametersByName(ssmClient, []string{resource})
+	if err != nil {
+		return err
+	}
+	if len(invalid) != 0 {
+		return fmt.Errorf("parameter not found")
+	}
+	return nil
+}
+
+// ErrorSummary shortens AWS API errors to their code and message.
+func ErrorSummary(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("%s: %s", apiErr.ErrorCode(), apiErr.ErrorMessage())
+	}
+	return err.Error()
+}
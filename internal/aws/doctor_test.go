@@ -0,0 +1,193 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestErrorSummary(t *testing.T) {
+	apiErr := &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not allowed"}
+	wrapped := fmt.Errorf("operation GetParameter: %w", apiErr)
+
+	if got := ErrorSummary(wrapped); got != "AccessDeniedException: not allowed" {
+		t.Errorf("got %q, want the API error code and message", got)
+	}
+	if got := ErrorSummary(errors.New("no credentials")); got != "no credentials" {
+		t.Errorf("got %q, want the error unchanged", got)
+	}
+}
+
+// Point the AWS SDK at an empty environment, with static credentials and
+// a shared config file holding a few profiles.
+func setTestAwsEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	content := "[default]\n[profile other]\n[profile from-env]\n"
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	for _, name := range []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_SESSION_TOKEN"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+// Fake AWS clients for doctor, failing for a few regions and resources.
+type fakeDoctorClient struct {
+	region string
+	calls  *[]string
+}
+
+func (c *fakeDoctorClient) GetCallerIdentity(ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options)) (*sts.GetCallerIdentityOutput, error) {
+	*c.calls = append(*c.calls, c.region)
+	if c.region == "eu-west-1" {
+		return nil, &smithy.GenericAPIError{Code: "ExpiredToken", Message: "expired"}
+	}
+	return &sts.GetCallerIdentityOutput{
+		Account: aws.String("123456789012"),
+		Arn:     aws.String("arn:aws:iam::123456789012:user/" + c.region),
+	}, nil
+}
+
+func (c *fakeDoctorClient) GetParametersByPath(ctx context.Context, params *ssm.GetParametersByPathInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	if aws.ToString(params.Path) == "/denied" {
+		return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not allowed"}
+	}
+	return &ssm.GetParametersByPathOutput{}, nil
+}
+
+func (c *fakeDoctorClient) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	output := &ssm.GetParametersOutput{}
+	for _, name := range params.Names {
+		if name == "/missing" {
+			output.InvalidParameters = append(output.InvalidParameters, name)
+		}
+	}
+	return output, nil
+}
+
+func (c *fakeDoctorClient) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	if aws.ToString(params.SecretId) == "denied" {
+		return nil, &smithy.GenericAPIError{Code: "AccessDeniedException", Message: "not allowed"}
+	}
+	return &secretsmanager.GetSecretValueOutput{}, nil
+}
+
+// Doctor clients that are all the same fake, recording the regions of STS calls.
+func fakeDoctorClients(calls *[]string) doctorClients {
+	return doctorClients{
+		sts: func(awsConfig aws.Config) callerIdentityAPI {
+			return &fakeDoctorClient{region: awsConfig.Region, calls: calls}
+		},
+		ssm: func(awsConfig aws.Config) ssmProbeAPI {
+			return &fakeDoctorClient{region: awsConfig.Region, calls: calls}
+		},
+		secretsManager: func(awsConfig aws.Config) secretValueAPI {
+			return &fakeDoctorClient{region: awsConfig.Region, calls: calls}
+		},
+	}
+}
+
+func TestGetIdentities(t *testing.T) {
+	setTestAwsEnvironment(t)
+	calls := make([]string, 0)
+
+	targets := []core.Target{
+		{Resource: "/a", Region: "us-east-1"},
+		{Resource: "/b", Region: "us-east-1"},
+		{Resource: "/c", Region: "eu-west-1"},
+		{Resource: "/d", Region: "us-east-1", Profile: "other"},
+		{Resource: "/e", Region: "eu-west-1"},
+	}
+	identities := getIdentities(targets, fakeDoctorClients(&calls))
+
+	// One identity per region and profile, in the order they are first used.
+	if want := []string{"us-east-1", "eu-west-1", "us-east-1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("got STS calls in %v, want %v", calls, want)
+	}
+	if len(identities) != 3 {
+		t.Fatalf("got %d identities, want 3", len(identities))
+	}
+
+	want := []Identity{
+		{Region: "us-east-1", Profile: "default", Account: "123456789012", Arn: "arn:aws:iam::123456789012:user/us-east-1"},
+		{Region: "eu-west-1", Profile: "default"},
+		{Region: "us-east-1", Profile: "other", Account: "123456789012", Arn: "arn:aws:iam::123456789012:user/us-east-1"},
+	}
+	for i, identity := range identities {
+		failed := identity.Error != nil
+		identity.Error = nil
+		if identity != want[i] {
+			t.Errorf("identity %d: got %+v, want %+v", i, identity, want[i])
+		}
+		if wantFailed := i == 1; failed != wantFailed {
+			t.Errorf("identity %d: failed %t, want %t", i, failed, wantFailed)
+		}
+	}
+}
+
+func TestGetIdentitiesProfileFromEnvironment(t *testing.T) {
+	setTestAwsEnvironment(t)
+	t.Setenv("AWS_PROFILE", "from-env")
+	calls := make([]string, 0)
+
+	targets := []core.Target{{Resource: "/a", Region: "us-east-1"}, {Resource: "/b", Region: "us-east-1", Profile: "other"}}
+	identities := getIdentities(targets, fakeDoctorClients(&calls))
+
+	if len(identities) != 2 || identities[0].Profile != "from-env" || identities[1].Profile != "other" {
+		t.Errorf("got %+v, want the profiles from-env and other", identities)
+	}
+}
+
+func TestProbeTarget(t *testing.T) {
+	setTestAwsEnvironment(t)
+	calls := make([]string, 0)
+
+	tests := []struct {
+		store  string
+		target core.Target
+		error  string
+	}{
+		{store: Store_SsmParameterStore, target: core.Target{Resource: "/app/**", Region: "us-east-1"}},
+		{store: Store_SsmParameterStore, target: core.Target{Resource: "/app/DB_HOST", Region: "us-east-1"}},
+		{store: Store_SsmParameterStore, target: core.Target{Resource: "/denied/*", Region: "us-east-1"}, error: "AccessDeniedException: not allowed"},
+		{store: Store_SsmParameterStore, target: core.Target{Resource: "/missing", Region: "us-east-1"}, error: "parameter not found"},
+		{store: Store_SecretsManager, target: core.Target{Resource: "app/secret", Region: "us-east-1"}},
+		{store: Store_SecretsManager, target: core.Target{Resource: "denied", Region: "us-east-1"}, error: "AccessDeniedException: not allowed"},
+		{store: Store_SecretsManager, target: core.Target{Resource: "app/secret"}, error: "no region is set"},
+		{store: "vault", target: core.Target{Resource: "app/secret", Region: "us-east-1"}, error: `unsupported store "vault"`},
+	}
+
+	for _, test := range tests {
+		probe := probeTarget(test.store, test.target, fakeDoctorClients(&calls))
+		if probe.Store != test.store || probe.Resource != test.target.Resource || probe.Profile != "default" {
+			t.Errorf("%s %s: got %+v, want the store, resource and default profile", test.store, test.target.Resource, probe)
+		}
+
+		got := ""
+		if probe.Error != nil {
+			got = ErrorSummary(probe.Error)
+		}
+		if got != test.error {
+			t.Errorf("%s %s: got error %q, want %q", test.store, test.target.Resource, got, test.error)
+		}
+	}
+}
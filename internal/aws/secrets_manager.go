@@ -4,10 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/spf13/viper"
 
@@ -15,22 +13,38 @@ import (
 	"github.com/divergentcodes/labrador/internal/variable"
 )
 
-// Fetch values from AWS Secrets Manager.
-func FetchSecretsManager() (map[string]*variable.Variable, error) {
+// Fetch values from AWS Secrets Manager, as configured in a viper instance.
+func FetchSecretsManager(v *viper.Viper) (map[string]*variable.Variable, error) {
 
-	smClient := initSecretsManagerClient()
-	secretsManagerResources := viper.GetStringSlice(core.OptStr_AWS_SecretManager)
+	smTargets, err := configuredTargets(v, core.OptStr_AWS_SecretManager)
+	if err != nil {
+		return nil, err
+	}
+
+	smClients := make(map[string]*secretsmanager.Client, 0)
 	secretsManagerVariables := make(map[string]*variable.Variable, 0)
 
 	core.PrintVerbose("\nFetching Secrets Manager values...")
-	for _, resource := range secretsManagerResources {
-		core.PrintDebug(fmt.Sprintf("\n\t%s", resource))
+	for _, target := range smTargets {
+		core.PrintDebug(fmt.Sprintf("\n\t%s", target.Resource))
 	}
 
 	// Fetch and aggregate the parameter resources.
-	for _, resource := range secretsManagerResources {
-		smSecretsManagerResultBatch := fetchSecretsManagerSecret(smClient, resource)
+	for i, target := range smTargets {
+		smClient, err := getSecretsManagerClient(smClients, target)
+		if err != nil {
+			return nil, err
+		}
+		smSecretsManagerResultBatch, err := fetchSecretsManagerSecret(smClient, target.Resource)
+		if err != nil {
+			return nil, err
+		}
+		smSecretsManagerResultBatch, err = applyTargetTransforms(smSecretsManagerResultBatch, target)
+		if err != nil {
+			return nil, err
+		}
 		for name, variable := range smSecretsManagerResultBatch {
+			variable.TargetIndex = i
 			secretsManagerVariables[name] = variable
 		}
 	}
@@ -38,36 +52,39 @@ func FetchSecretsManager() (map[string]*variable.Variable, error) {
 	return secretsManagerVariables, nil
 }
 
-// Initialize a AWS Secrets Manager client instance.
-func initSecretsManagerClient() *secretsmanager.Client {
-	awsRegion := viper.GetString(core.OptStr_AWS_Region)
-
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	awsConfig, err := config.LoadDefaultConfig(
-		context.TODO(),
-	)
+// Get the Secrets Manager client for a target's region and credentials, initializing it on first use.
+func getSecretsManagerClient(smClients map[string]*secretsmanager.Client, target core.Target) (*secretsmanager.Client, error) {
+	key := clientKey(target)
+	if smClient, ok := smClients[key]; ok {
+		return smClient, nil
+	}
+
+	smClient, err := initSecretsManagerClient(target)
 	if err != nil {
-		log.Fatalf("unable to load AWS SDK config, %v", err)
+		return nil, err
 	}
-	if awsRegion != "" {
-		awsConfig.Region = awsRegion
-		core.PrintDebug(fmt.Sprintf("\nSet AWS region: %s", awsRegion))
+	smClients[key] = smClient
+
+	return smClient, nil
+}
+
+// Initialize a AWS Secrets Manager client instance.
+func initSecretsManagerClient(target core.Target) (*secretsmanager.Client, error) {
+
+	awsConfig, err := loadAwsConfig(target)
+	if err != nil {
+		return nil, err
 	}
 
 	core.PrintDebug("\n")
 	core.PrintVerbose("\nInitializing AWS Secrets Manager client...")
 	smClient := secretsmanager.NewFromConfig(awsConfig)
-	if err != nil {
-		log.Fatalf("failed to initialize AWS Secrets Manager client, %v", err)
-	}
 
-	return smClient
+	return smClient, nil
 }
 
 // Fetch a secret from AWS Secrets Manager.
-func fetchSecretsManagerSecret(smClient *secretsmanager.Client, resource string) map[string]*variable.Variable {
+func fetchSecretsManagerSecret(smClient *secretsmanager.Client, resource string) (map[string]*variable.Variable, error) {
 	// Using a map to be consistent with the wilcard fetching.
 	smSecretResults := make(map[string]*variable.Variable, 0)
 
@@ -78,12 +95,12 @@ func fetchSecretsManagerSecret(smClient *secretsmanager.Client, resource string)
 
 	resp, err := smClient.GetSecretValue(context.TODO(), input)
 	if err != nil {
-		log.Fatalf("failed to fetch AWS Secrets Manager values, %v", err)
+		return nil, fmt.Errorf("failed to fetch AWS Secrets Manager values, %w", err)
 	}
 
 	smSecretResults = secretToVariables(resp, smSecretResults)
 
-	return smSecretResults
+	return smSecretResults, nil
 }
 
 // Convert an AWS Secrets Manager secret to a list of Variables.
@@ -105,7 +122,7 @@ func secretToVariables(secret *secretsmanager.GetSecretValueOutput, smSecretVari
 		// Format each key/value pair as a variable.
 		for k, v := range secretDict {
 			result := variable.Variable{
-				Source:   "aws-secrets-manager",
+				Source:   variable.Source_AwsSecretsManager,
 				Key:      k,
 				Value:    v,
 				Metadata: make(map[string]string),
@@ -123,7 +140,7 @@ func secretToVariables(secret *secretsmanager.GetSecretValueOutput, smSecretVari
 		varType = "SecretBinary"
 
 		result := variable.Variable{
-			Source:   "aws-secrets-manager",
+			Source:   variable.Source_AwsSecretsManager,
 			Key:      *secret.Name,
 			Value:    string(secret.SecretBinary[:]),
 			Metadata: make(map[string]string),
@@ -0,0 +1,137 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smTypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// A Secrets Manager secret, holding all values as one JSON object.
+type secretsManagerSecret struct {
+	client *secretsmanager.Client
+	name   string
+	opts   WriteOptions
+
+	// Region and profile, for messages.
+	location string
+
+	// Whether the secret existed when last read.
+	exists bool
+}
+
+// Create a store for a target's secret.
+func newSecretsManagerSecret(target core.Target, opts WriteOptions) (*secretsManagerSecret, error) {
+	client, err := initSecretsManagerClient(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secretsManagerSecret{client: client, name: target.Resource, opts: opts, location: targetLocation(target)}, nil
+}
+
+func (store *secretsManagerSecret) String() string {
+	return fmt.Sprintf("Secrets Manager secret %s%s", store.name, store.location)
+}
+
+// Read the key/value pairs in the secret. A missing secret is empty.
+func (store *secretsManagerSecret) Read() (map[string]string, error) {
+	values := make(map[string]string, 0)
+
+	input := &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(store.name),
+	}
+	resp, err := store.client.GetSecretValue(context.TODO(), input)
+	if err != nil {
+		var notFound *smTypes.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			store.exists = false
+			return values, nil
+		}
+		return nil, fmt.Errorf("failed to read AWS Secrets Manager secret %s, %w", store.name, err)
+	}
+	store.exists = true
+
+	if resp.SecretString == nil {
+		return nil, fmt.Errorf("secret %s is binary, not a JSON object of key/value pairs", store.name)
+	}
+	if err := json.Unmarshal([]byte(*resp.SecretString), &values); err != nil {
+		return nil, fmt.Errorf("secret %s is not a JSON object of key/value pairs", store.name)
+	}
+
+	return values, nil
+}
+
+// Write the current values with the changes applied, as a new secret version.
+//
+// The KMS key only applies when the secret is created.
+func (store *secretsManagerSecret) Apply(current map[string]string, changes []variable.Difference) error {
+	values := make(map[string]string, len(current))
+	for name, value := range current {
+		values[name] = value
+	}
+	for _, change := range changes {
+		if change.Change == variable.Change_Removed {
+			delete(values, change.Name)
+		} else {
+			values[change.Name] = change.New
+		}
+		core.PrintVerbose(fmt.Sprintf("\n\t%s %s in %s", change.Change, change.Name, store.name))
+	}
+
+	// Map keys are sorted when encoded, so versions only differ by their changes.
+	secretString, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	tags := make([]smTypes.Tag, 0, len(store.opts.Tags))
+	for _, key := range sortedKeys(store.opts.Tags) {
+		tags = append(tags, smTypes.Tag{Key: aws.String(key), Value: aws.String(store.opts.Tags[key])})
+	}
+
+	if !store.exists {
+		input := &secretsmanager.CreateSecretInput{
+			Name:         aws.String(store.name),
+			SecretString: aws.String(string(secretString)),
+		}
+		if store.opts.KmsKeyId != "" {
+			input.KmsKeyId = aws.String(store.opts.KmsKeyId)
+		}
+		if len(tags) != 0 {
+			input.Tags = tags
+		}
+		if _, err := store.client.CreateSecret(context.TODO(), input); err != nil {
+			return fmt.Errorf("failed to create AWS Secrets Manager secret %s, %w", store.name, err)
+		}
+		store.exists = true
+		return nil
+	}
+
+	input := &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(store.name),
+		SecretString: aws.String(string(secretString)),
+	}
+	if _, err := store.client.PutSecretValue(context.TODO(), input); err != nil {
+		return fmt.Errorf("failed to update AWS Secrets Manager secret %s, %w", store.name, err)
+	}
+
+	if len(tags) != 0 {
+		tagInput := &secretsmanager.TagResourceInput{
+			SecretId: aws.String(store.name),
+			Tags:     tags,
+		}
+		if _, err := store.client.TagResource(context.TODO(), tagInput); err != nil {
+			return fmt.Errorf("failed to tag AWS Secrets Manager secret %s, %w", store.name, err)
+		}
+	}
+
+	return nil
+}
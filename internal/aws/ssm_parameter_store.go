@@ -4,11 +4,9 @@ package aws
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
 	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
 	"github.com/spf13/viper"
@@ -17,117 +15,297 @@ import (
 	"github.com/divergentcodes/labrador/internal/variable"
 )
 
-// Fetch values from AWS SSM Parameter Store.
-func FetchParameterStore() (map[string]*variable.Variable, error) {
+// SSM parameter naming modes.
+const (
+	// Name variables after the last segment of the parameter path.
+	SsmNaming_Leaf = "leaf"
+	// Name variables after the parameter path relative to the wildcard root,
+	// in upper case.
+	SsmNaming_Relative = "relative"
+)
+
+// Maximum number of parameters that SSM returns per call.
+const ssmBatchSize = 10
+
+// The SSM call that fetches parameters by name, so it can be replaced in tests.
+type ssmGetParametersAPI interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// Fetch values from AWS SSM Parameter Store, as configured in a viper instance.
+func FetchParameterStore(v *viper.Viper) (map[string]*variable.Variable, error) {
+
+	ssmTargets, err := configuredTargets(v, core.OptStr_AWS_SsmParameterStore)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate naming modes before making any calls.
+	for i := range ssmTargets {
+		if ssmTargets[i].Naming == "" {
+			ssmTargets[i].Naming = v.GetString(core.OptStr_AWS_SsmNaming)
+		}
+		naming := ssmTargets[i].Naming
+		if naming != SsmNaming_Leaf && naming != SsmNaming_Relative {
+			return nil, fmt.Errorf("unknown SSM naming mode %q for %s", naming, ssmTargets[i].Resource)
+		}
+	}
 
-	ssmClient := initSsmClient()
-	ssmParameterResources := viper.GetStringSlice(core.OptStr_AWS_SsmParameterStore)
+	ssmClients := make(map[string]*ssm.Client, 0)
 	ssmParameterVariables := make(map[string]*variable.Variable, 0)
 
 	core.PrintVerbose("\nFetching SSM Parameter Store values...")
-	for _, resource := range ssmParameterResources {
-		core.PrintDebug(fmt.Sprintf("\n\t%s", resource))
+	for _, target := range ssmTargets {
+		core.PrintDebug(fmt.Sprintf("\n\t%s", target.Resource))
 	}
 
-	// Fetch and aggregate the parameter resources.
-	for _, resource := range ssmParameterResources {
-		if strings.HasSuffix(resource, "/*") {
-			// Wildcard parameter paths.
-			ssmParameterResultBatch := fetchParameterStoreWildcard(ssmClient, resource)
-			for name, variable := range ssmParameterResultBatch {
-				ssmParameterVariables[name] = variable
+	// Results are kept per target, so they can be aggregated in declared order.
+	ssmTargetResults := make([]map[string]*variable.Variable, len(ssmTargets))
+
+	// Wildcard targets are fetched by path. Explicit targets are grouped by
+	// region and credentials, to be fetched in batches.
+	batchClientKeys := make([]string, 0)
+	batchTargets := make(map[string][]int, 0)
+	for i, target := range ssmTargets {
+		root, recursive, ok := parseWildcardPath(target.Resource)
+		if !ok {
+			if len(target.Filters) != 0 {
+				core.PrintWarning(fmt.Sprintf("filters only apply to wildcard paths, ignoring them for %s", target.Resource))
+			}
+			key := clientKey(target)
+			if _, exists := batchTargets[key]; !exists {
+				batchClientKeys = append(batchClientKeys, key)
 			}
-		} else {
-			// Single parameter paths.
-			ssmParameterResultBatch := fetchParameterStoreSingle(ssmClient, resource)
-			for name, variable := range ssmParameterResultBatch {
-				ssmParameterVariables[name] = variable
+			batchTargets[key] = append(batchTargets[key], i)
+			continue
+		}
+
+		ssmClient, err := getSsmClient(ssmClients, target)
+		if err != nil {
+			return nil, err
+		}
+		var notFound []string
+		ssmTargetResults[i], notFound, err = fetchParameterStoreWildcard(ssmClient, root, recursive, target)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range notFound {
+			warnParameterNotFound(name, i, target)
+		}
+	}
+
+	for _, key := range batchClientKeys {
+		indexes := batchTargets[key]
+		ssmClient, err := getSsmClient(ssmClients, ssmTargets[indexes[0]])
+		if err != nil {
+			return nil, err
+		}
+		notFound, err := fetchParameterStoreBatch(ssmClient, ssmTargets, indexes, ssmTargetResults)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range indexes {
+			for _, name := range notFound[i] {
+				warnParameterNotFound(name, i, ssmTargets[i])
 			}
 		}
 	}
 
+	// Aggregate the parameter resources. Last target has highest precedence.
+	for i := range ssmTargetResults {
+		ssmParameterResultBatch, err := applyTargetTransforms(ssmTargetResults[i], ssmTargets[i])
+		if err != nil {
+			return nil, err
+		}
+		for name, variable := range ssmParameterResultBatch {
+			variable.TargetIndex = i
+			ssmParameterVariables[name] = variable
+		}
+	}
+
 	return ssmParameterVariables, nil
 }
 
-// Initialize a SSM client.
-func initSsmClient() *ssm.Client {
-	awsRegion := viper.GetString(core.OptStr_AWS_Region)
-
-	// Using the SDK's default configuration, loading additional config
-	// and credentials values from the environment variables, shared
-	// credentials, and shared configuration files
-	awsConfig, err := config.LoadDefaultConfig(
-		context.TODO(),
-	)
-	if err != nil {
-		log.Fatalf("unable to load AWS SDK config, %v", err)
+// Split a wildcard parameter path into its root path and whether to recurse.
+//
+// "/path/*" matches the direct children of "/path", while "/path/**"
+// matches everything below "/path".
+func parseWildcardPath(resource string) (string, bool, bool) {
+	var root string
+	var recursive bool
+
+	switch {
+	case strings.HasSuffix(resource, "/**"):
+		root = strings.TrimSuffix(resource, "/**")
+		recursive = true
+	case strings.HasSuffix(resource, "/*"):
+		root = strings.TrimSuffix(resource, "/*")
+		recursive = false
+	default:
+		return resource, false, false
 	}
-	if awsRegion != "" {
-		awsConfig.Region = awsRegion
-		core.PrintDebug(fmt.Sprintf("\nSet AWS region: %s", awsRegion))
+
+	if root == "" {
+		root = "/"
 	}
 
-	core.PrintVerbose("\nInitializing AWS SSM client...")
-	ssmClient := ssm.NewFromConfig(awsConfig)
+	return root, recursive, true
+}
+
+// Warn that a parameter requested by a target doesn't exist, naming the
+// target by its position in the config.
+func warnParameterNotFound(name string, index int, target core.Target) {
+	core.PrintWarning(fmt.Sprintf("SSM parameter not found: %s, requested by %s[%d]%s",
+		name, core.OptStr_AWS_SsmParameterStore, index, targetLocation(target)))
+}
+
+// Get the SSM client for a target's region and credentials, initializing it on first use.
+func getSsmClient(ssmClients map[string]*ssm.Client, target core.Target) (*ssm.Client, error) {
+	key := clientKey(target)
+	if ssmClient, ok := ssmClients[key]; ok {
+		return ssmClient, nil
+	}
+
+	ssmClient, err := initSsmClient(target)
 	if err != nil {
-		log.Fatalf("failed to initialize AWS SSM client, %v", err)
+		return nil, err
 	}
+	ssmClients[key] = ssmClient
 
-	return ssmClient
+	return ssmClient, nil
 }
 
-// Recursively fetch all parameters at a SSM parameter store wildcard path.
-func fetchParameterStoreSingle(ssmClient *ssm.Client, resource string) map[string]*variable.Variable {
+// Initialize a SSM client.
+func initSsmClient(target core.Target) (*ssm.Client, error) {
+
+	awsConfig, err := loadAwsConfig(target)
+	if err != nil {
+		return nil, err
+	}
 
-	// Using a map to be consistent with the wilcard fetching.
-	ssmParameterResults := make(map[string]*variable.Variable, 0)
+	core.PrintVerbose("\nInitializing AWS SSM client...")
+	ssmClient := ssm.NewFromConfig(awsConfig)
+
+	return ssmClient, nil
+}
+
+// Fetch explicitly named parameters from SSM parameter store in batches.
+//
+// Results are stored in ssmTargetResults at each target's index. Parameters
+// that don't exist are returned per target index, instead of failing the run.
+func fetchParameterStoreBatch(ssmClient ssmGetParametersAPI, ssmTargets []core.Target, indexes []int, ssmTargetResults []map[string]*variable.Variable) (map[int][]string, error) {
 
-	input := &ssm.GetParameterInput{
-		Name:           aws.String(resource),
-		WithDecryption: aws.Bool(true),
+	// The same parameter can be declared by more than one target.
+	names := make([]string, 0)
+	nameTargets := make(map[string][]int, 0)
+	for _, i := range indexes {
+		name := ssmTargets[i].Resource
+		if _, exists := nameTargets[name]; !exists {
+			names = append(names, name)
+		}
+		nameTargets[name] = append(nameTargets[name], i)
+		// Using a map to be consistent with the wilcard fetching.
+		ssmTargetResults[i] = make(map[string]*variable.Variable, 0)
 	}
 
-	resp, err := ssmClient.GetParameter(context.TODO(), input)
+	parameters, invalidParameters, err := getParametersByName(ssmClient, names)
 	if err != nil {
-		log.Fatalf("failed to fetch AWS SSM Parameter Store values, %v", err)
+		return nil, err
+	}
+
+	for i := range parameters {
+		parameter := &parameters[i]
+
+		// Parameters can be requested by name or ARN, with an optional selector.
+		selector := aws.ToString(parameter.Selector)
+		for _, requested := range []string{*parameter.Name + selector, *parameter.ARN + selector} {
+			for _, targetIndex := range nameTargets[requested] {
+				// Convert the result to a canonical variable.
+				result := parameterToVariable(parameter, "", SsmNaming_Leaf)
+				ssmTargetResults[targetIndex][result.Key] = result
+			}
+		}
+	}
+
+	// Invalid names are returned as they were requested.
+	notFound := make(map[int][]string, 0)
+	for _, name := range invalidParameters {
+		for _, targetIndex := range nameTargets[name] {
+			notFound[targetIndex] = append(notFound[targetIndex], name)
+		}
 	}
 
-	// Convert the result to a canonical variable.
-	result := parameterToVariable(resp.Parameter)
-	ssmParameterResults[result.Key] = result
+	return notFound, nil
+}
+
+// Fetch parameters by name or ARN, in batches of 10.
+//
+// Returns the fetched parameters, and the names that don't exist.
+func getParametersByName(ssmClient ssmGetParametersAPI, names []string) ([]ssmTypes.Parameter, []string, error) {
+	parameters := make([]ssmTypes.Parameter, 0)
+	invalidParameters := make([]string, 0)
+
+	// Only 10 parameters can be fetched per call.
+	for start := 0; start < len(names); start += ssmBatchSize {
+		end := start + ssmBatchSize
+		if end > len(names) {
+			end = len(names)
+		}
+
+		input := &ssm.GetParametersInput{
+			Names:          names[start:end],
+			WithDecryption: aws.Bool(true),
+		}
+
+		resp, err := ssmClient.GetParameters(context.TODO(), input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch AWS SSM Parameter Store values, %w", err)
+		}
 
-	return ssmParameterResults
+		parameters = append(parameters, resp.Parameters...)
+		invalidParameters = append(invalidParameters, resp.InvalidParameters...)
+	}
+
+	return parameters, invalidParameters, nil
 }
 
-// Recursively fetch all parameters at a SSM parameter store wildcard path.
-func fetchParameterStoreWildcard(ssmClient *ssm.Client, resource string) map[string]*variable.Variable {
+// Fetch all parameters at a SSM parameter store wildcard path.
+//
+// Also returns the names of listed parameters that couldn't be fetched.
+func fetchParameterStoreWildcard(ssmClient *ssm.Client, root string, recursive bool, target core.Target) (map[string]*variable.Variable, []string, error) {
+
+	pathFilters, localFilters, describe, err := planParameterFilters(target.Filters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid filters for %s: %w", target.Resource, err)
+	}
+	if describe {
+		return fetchParameterStoreDescribed(ssmClient, root, recursive, target.Naming, pathFilters, localFilters)
+	}
 
-	recursive := true
 	nextToken := ""
 	ssmParameterResults := make(map[string]*variable.Variable, 0)
 
-	resource = strings.TrimRight(resource, "/*")
-
 	// Only 10 parameters can be fetched per call. Loop to fetch all.
 	for {
 		input := &ssm.GetParametersByPathInput{
-			Path:           aws.String(resource),
-			Recursive:      aws.Bool(recursive),
-			WithDecryption: aws.Bool(true),
-			MaxResults:     aws.Int32(10),
-			NextToken:      aws.String(nextToken),
+			Path:             aws.String(root),
+			Recursive:        aws.Bool(recursive),
+			WithDecryption:   aws.Bool(true),
+			MaxResults:       aws.Int32(ssmBatchSize),
+			NextToken:        aws.String(nextToken),
+			ParameterFilters: pathFilters,
 		}
 
 		// Fetch the parameters.
 		resp, err := ssmClient.GetParametersByPath(context.TODO(), input)
 		if err != nil {
-			log.Fatalf("failed to fetch SSM parameters, %v", err)
+			return nil, nil, fmt.Errorf("failed to fetch SSM parameters, %w", err)
 		}
 
 		// Aggregate the parameters, since the call can be recursive.
 		// Last variable has highest precendence.
 		for i := range resp.Parameters {
-			result := parameterToVariable(&resp.Parameters[i])
+			result := parameterToVariable(&resp.Parameters[i], root, target.Naming)
 			ssmParameterResults[result.Key] = result
 		}
 
@@ -138,17 +316,169 @@ func fetchParameterStoreWildcard(ssmClient *ssm.Client, resource string) map[str
 		nextToken = *resp.NextToken
 	}
 
-	return ssmParameterResults
+	return ssmParameterResults, nil, nil
+}
+
+// Fetch the parameters at a wildcard path that match filters GetParametersByPath doesn't support.
+//
+// Matching parameter names are listed with DescribeParameters, and then
+// their values are fetched in batches. Listed parameters that can't be
+// fetched anymore, like ones deleted in between, are returned by name.
+func fetchParameterStoreDescribed(ssmClient *ssm.Client, root string, recursive bool, naming string, remoteFilters []ssmTypes.ParameterStringFilter, localFilters []core.ParameterFilter) (map[string]*variable.Variable, []string, error) {
+
+	pathOption := "OneLevel"
+	if recursive {
+		pathOption = "Recursive"
+	}
+	pathFilter := ssmTypes.ParameterStringFilter{
+		Key:    aws.String("Path"),
+		Option: aws.String(pathOption),
+		Values: []string{root},
+	}
+
+	names := make([]string, 0)
+	var nextToken *string
+	for {
+		input := &ssm.DescribeParametersInput{
+			ParameterFilters: append([]ssmTypes.ParameterStringFilter{pathFilter}, remoteFilters...),
+			MaxResults:       aws.Int32(50),
+			NextToken:        nextToken,
+		}
+
+		resp, err := ssmClient.DescribeParameters(context.TODO(), input)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to describe SSM parameters, %w", err)
+		}
+
+		for i := range resp.Parameters {
+			if matchesLocalFilters(&resp.Parameters[i], localFilters) {
+				names = append(names, *resp.Parameters[i].Name)
+			}
+		}
+
+		if resp.NextToken == nil {
+			break
+		}
+		nextToken = resp.NextToken
+	}
+
+	parameters, invalidParameters, err := getParametersByName(ssmClient, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ssmParameterResults := make(map[string]*variable.Variable, 0)
+	for i := range parameters {
+		result := parameterToVariable(&parameters[i], root, naming)
+		ssmParameterResults[result.Key] = result
+	}
+
+	return ssmParameterResults, invalidParameters, nil
+}
+
+// Filter keys that GetParametersByPath can evaluate.
+var pathFilterKeys = map[string]bool{"Type": true, "KeyId": true, "Label": true}
+
+// Filter keys that can be compared against parameter metadata locally.
+var localFilterKeys = map[string]bool{"Type": true, "KeyId": true, "Tier": true, "DataType": true, "Name": true}
+
+// Check parameter filters, and decide how each one is evaluated.
+//
+// Filters are sent with GetParametersByPath when it supports all of them.
+// Otherwise DescribeParameters is needed, which supports tiers and tags,
+// but not labels. NotEquals has no API equivalent, so it is evaluated
+// locally against parameter metadata.
+func planParameterFilters(filters []core.ParameterFilter) ([]ssmTypes.ParameterStringFilter, []core.ParameterFilter, bool, error) {
+	remoteFilters := make([]ssmTypes.ParameterStringFilter, 0)
+	localFilters := make([]core.ParameterFilter, 0)
+	describe := false
+	hasLabel := false
+
+	for _, filter := range filters {
+		if filter.Option == "" {
+			filter.Option = "Equals"
+		}
+		if len(filter.Values) == 0 {
+			return nil, nil, false, fmt.Errorf("filter %s has no values", filter.Key)
+		}
+
+		isTag := strings.HasPrefix(filter.Key, "tag:")
+		if !isTag && !pathFilterKeys[filter.Key] && !localFilterKeys[filter.Key] {
+			return nil, nil, false, fmt.Errorf("unknown filter key %q", filter.Key)
+		}
+
+		switch filter.Option {
+		case "NotEquals":
+			if !localFilterKeys[filter.Key] {
+				return nil, nil, false, fmt.Errorf("filter key %s does not support NotEquals", filter.Key)
+			}
+			localFilters = append(localFilters, filter)
+			describe = true
+			continue
+		case "Equals":
+		case "BeginsWith":
+			describe = true
+		default:
+			return nil, nil, false, fmt.Errorf("unknown filter option %q", filter.Option)
+		}
+
+		if filter.Key == "Label" {
+			hasLabel = true
+		} else if !pathFilterKeys[filter.Key] {
+			describe = true
+		}
+
+		remoteFilters = append(remoteFilters, ssmTypes.ParameterStringFilter{
+			Key:    aws.String(filter.Key),
+			Option: aws.String(filter.Option),
+			Values: filter.Values,
+		})
+	}
+
+	if describe && hasLabel {
+		return nil, nil, false, fmt.Errorf("label filters can only be combined with Equals filters on Type and KeyId")
+	}
+
+	return remoteFilters, localFilters, describe, nil
+}
+
+// Check if parameter metadata passes all of the locally evaluated filters.
+func matchesLocalFilters(metadata *ssmTypes.ParameterMetadata, filters []core.ParameterFilter) bool {
+	for _, filter := range filters {
+		var field string
+		switch filter.Key {
+		case "Type":
+			field = string(metadata.Type)
+		case "KeyId":
+			field = aws.ToString(metadata.KeyId)
+		case "Tier":
+			field = string(metadata.Tier)
+		case "DataType":
+			field = aws.ToString(metadata.DataType)
+		case "Name":
+			field = aws.ToString(metadata.Name)
+		}
+
+		// NotEquals is the only locally evaluated option.
+		for _, value := range filter.Values {
+			if field == value {
+				return false
+			}
+		}
+	}
+	return true
 }
 
 // Convert a parameter store resource to an intermediate labrador variable representation.
-func parameterToVariable(parameter *ssmTypes.Parameter) *variable.Variable {
+//
+// The root is the wildcard path the parameter was fetched from, used by the
+// relative naming mode.
+func parameterToVariable(parameter *ssmTypes.Parameter, root string, naming string) *variable.Variable {
 
-	splitArn := strings.Split(*parameter.ARN, "/")
-	varKey := splitArn[len(splitArn)-1]
+	varKey := parameterKey(parameter, root, naming)
 
 	result := variable.Variable{
-		Source:   "aws-ssm-parameter-store",
+		Source:   variable.Source_AwsSsmParameterStore,
 		Key:      varKey,
 		Value:    *parameter.Value,
 		Metadata: make(map[string]string),
@@ -161,3 +491,23 @@ func parameterToVariable(parameter *ssmTypes.Parameter) *variable.Variable {
 
 	return &result
 }
+
+// Derive a variable key from a parameter's path.
+//
+// The leaf mode uses the last path segment. The relative mode joins the
+// path segments below the wildcard root in upper case, like environment
+// variables are usually named, so "/app/db/PASSWORD" fetched from
+// "/app/**" becomes "DB_PASSWORD".
+func parameterKey(parameter *ssmTypes.Parameter, root string, naming string) string {
+
+	if naming == SsmNaming_Relative && root != "" {
+		relativePath := strings.TrimPrefix(*parameter.Name, root)
+		relativePath = strings.Trim(relativePath, "/")
+		if relativePath != "" {
+			return strings.ToUpper(strings.ReplaceAll(relativePath, "/", "_"))
+		}
+	}
+
+	splitArn := strings.Split(*parameter.ARN, "/")
+	return splitArn[len(splitArn)-1]
+}
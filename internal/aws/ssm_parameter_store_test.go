@@ -0,0 +1,344 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+func TestParseWildcardPath(t *testing.T) {
+	tests := []struct {
+		resource  string
+		root      string
+		recursive bool
+		wildcard  bool
+	}{
+		{resource: "/app/db/PASSWORD", root: "/app/db/PASSWORD"},
+		{resource: "/app/*", root: "/app", wildcard: true},
+		{resource: "/app/**", root: "/app", recursive: true, wildcard: true},
+		{resource: "/app/dev/**", root: "/app/dev", recursive: true, wildcard: true},
+		{resource: "/*", root: "/", wildcard: true},
+		{resource: "/**", root: "/", recursive: true, wildcard: true},
+		{resource: "/app*", root: "/app*"},
+	}
+	for _, test := range tests {
+		root, recursive, wildcard := parseWildcardPath(test.resource)
+		if root != test.root || recursive != test.recursive || wildcard != test.wildcard {
+			t.Errorf("parseWildcardPath(%q) = %q, %t, %t, want %q, %t, %t", test.resource,
+				root, recursive, wildcard, test.root, test.recursive, test.wildcard)
+		}
+	}
+}
+
+func TestParameterKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		root   string
+		naming string
+		want   string
+	}{
+		{name: "/app/db/PASSWORD", root: "/app", naming: SsmNaming_Leaf, want: "PASSWORD"},
+		{name: "/app/db/PASSWORD", root: "/app", naming: SsmNaming_Relative, want: "DB_PASSWORD"},
+		{name: "/app/cache/password", root: "/app", naming: SsmNaming_Relative, want: "CACHE_PASSWORD"},
+		{name: "/app/a/b/c", root: "/app/", naming: SsmNaming_Relative, want: "A_B_C"},
+		{name: "/app/PORT", root: "/app", naming: SsmNaming_Relative, want: "PORT"},
+		// Explicit parameters have no root, so they always use the leaf.
+		{name: "/app/db/PASSWORD", root: "", naming: SsmNaming_Relative, want: "PASSWORD"},
+	}
+	for _, test := range tests {
+		parameter := &ssmTypes.Parameter{
+			Name: aws.String(test.name),
+			ARN:  aws.String("arn:aws:ssm:us-east-1:123456789012:parameter" + test.name),
+		}
+		if got := parameterKey(parameter, test.root, test.naming); got != test.want {
+			t.Errorf("parameterKey(%s, %q, %s) = %q, want %q", test.name, test.root, test.naming, got, test.want)
+		}
+	}
+}
+
+func TestPlanParameterFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		filters  []core.ParameterFilter
+		remote   int
+		local    int
+		describe bool
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name:    "path filters",
+			filters: []core.ParameterFilter{{Key: "Type", Values: []string{"SecureString"}}, {Key: "Label", Values: []string{"prod"}}},
+			remote:  2,
+		},
+		{
+			name:     "tier needs describe",
+			filters:  []core.ParameterFilter{{Key: "Tier", Values: []string{"Standard"}}},
+			remote:   1,
+			describe: true,
+		},
+		{
+			name:     "tags need describe",
+			filters:  []core.ParameterFilter{{Key: "tag:team", Values: []string{"platform"}}},
+			remote:   1,
+			describe: true,
+		},
+		{
+			name:     "begins with needs describe",
+			filters:  []core.ParameterFilter{{Key: "KeyId", Option: "BeginsWith", Values: []string{"alias/"}}},
+			remote:   1,
+			describe: true,
+		},
+		{
+			name:     "not equals is local",
+			filters:  []core.ParameterFilter{{Key: "Tier", Option: "NotEquals", Values: []string{"Advanced"}}},
+			local:    1,
+			describe: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			remote, local, describe, err := planParameterFilters(test.filters)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(remote) != test.remote || len(local) != test.local || describe != test.describe {
+				t.Errorf("got %d remote, %d local, describe %t, want %d, %d, %t",
+					len(remote), len(local), describe, test.remote, test.local, test.describe)
+			}
+			for _, filter := range remote {
+				if aws.ToString(filter.Option) == "" {
+					t.Errorf("filter %s has no option", aws.ToString(filter.Key))
+				}
+			}
+		})
+	}
+}
+
+func TestPlanParameterFiltersErrors(t *testing.T) {
+	tests := map[string][]core.ParameterFilter{
+		"no values":              {{Key: "Type"}},
+		"unknown key":            {{Key: "Color", Values: []string{"red"}}},
+		"unknown option":         {{Key: "Type", Option: "Contains", Values: []string{"String"}}},
+		"tag with not equals":    {{Key: "tag:team", Option: "NotEquals", Values: []string{"platform"}}},
+		"label with not equals":  {{Key: "Label", Option: "NotEquals", Values: []string{"prod"}}},
+		"label with a tier":      {{Key: "Label", Values: []string{"prod"}}, {Key: "Tier", Values: []string{"Standard"}}},
+		"label with begins with": {{Key: "Label", Values: []string{"prod"}}, {Key: "Type", Option: "BeginsWith", Values: []string{"Secure"}}},
+	}
+	for name, filters := range tests {
+		if _, _, _, err := planParameterFilters(filters); err == nil {
+			t.Errorf("%s: planning filters succeeded, want an error", name)
+		}
+	}
+}
+
+func TestMatchesLocalFilters(t *testing.T) {
+	metadata := &ssmTypes.ParameterMetadata{
+		Name:     aws.String("/app/db/PASSWORD"),
+		Type:     ssmTypes.ParameterTypeSecureString,
+		Tier:     ssmTypes.ParameterTierAdvanced,
+		KeyId:    aws.String("alias/app"),
+		DataType: aws.String("text"),
+	}
+
+	tests := []struct {
+		name    string
+		filters []core.ParameterFilter
+		want    bool
+	}{
+		{name: "no filters", want: true},
+		{
+			name:    "other tier",
+			filters: []core.ParameterFilter{{Key: "Tier", Option: "NotEquals", Values: []string{"Standard"}}},
+			want:    true,
+		},
+		{
+			name:    "same tier",
+			filters: []core.ParameterFilter{{Key: "Tier", Option: "NotEquals", Values: []string{"Standard", "Advanced"}}},
+			want:    false,
+		},
+		{
+			name: "all filters must pass",
+			filters: []core.ParameterFilter{
+				{Key: "Type", Option: "NotEquals", Values: []string{"String"}},
+				{Key: "KeyId", Option: "NotEquals", Values: []string{"alias/app"}},
+			},
+			want: false,
+		},
+		{
+			name:    "name",
+			filters: []core.ParameterFilter{{Key: "Name", Option: "NotEquals", Values: []string{"/app/db/PASSWORD"}}},
+			want:    false,
+		},
+	}
+
+	for _, test := range tests {
+		if got := matchesLocalFilters(metadata, test.filters); got != test.want {
+			t.Errorf("%s: matchesLocalFilters() = %t, want %t", test.name, got, test.want)
+		}
+	}
+}
+
+const testParameterArnPrefix = "arn:aws:ssm:us-east-1:123456789012:parameter"
+
+// Fake SSM client holding parameter values by name, at version 1.
+type fakeGetParameters struct {
+	values map[string]string
+	calls  [][]string
+}
+
+func (c *fakeGetParameters) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	c.calls = append(c.calls, params.Names)
+	if len(params.Names) > ssmBatchSize {
+		return nil, fmt.Errorf("too many names: %d", len(params.Names))
+	}
+
+	output := &ssm.GetParametersOutput{}
+	for _, requested := range params.Names {
+		// Selectors follow the last colon, which ARNs have before the path.
+		name, selector := requested, ""
+		if i := strings.LastIndex(requested, ":"); i != -1 && !strings.Contains(requested[i:], "/") {
+			name, selector = requested[:i], requested[i:]
+		}
+		name = strings.TrimPrefix(name, testParameterArnPrefix)
+
+		value, ok := c.values[name]
+		if !ok || (selector != "" && selector != ":1") {
+			output.InvalidParameters = append(output.InvalidParameters, requested)
+			continue
+		}
+		parameter := ssmTypes.Parameter{
+			Name:             aws.String(name),
+			ARN:              aws.String(testParameterArnPrefix + name),
+			Value:            aws.String(value),
+			Type:             ssmTypes.ParameterTypeSecureString,
+			Version:          1,
+			LastModifiedDate: aws.Time(time.Unix(0, 0)),
+		}
+		if selector != "" {
+			parameter.Selector = aws.String(selector)
+		}
+		output.Parameters = append(output.Parameters, parameter)
+	}
+	return output, nil
+}
+
+func TestGetParametersByName(t *testing.T) {
+	client := &fakeGetParameters{values: map[string]string{}}
+	names := make([]string, 0)
+	for i := 0; i < 24; i++ {
+		name := fmt.Sprintf("/app/P%02d", i)
+		client.values[name] = "value"
+		names = append(names, name)
+	}
+	names = append(names, "/app/MISSING")
+
+	parameters, invalid, err := getParametersByName(client, names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := make([]int, 0)
+	for _, call := range client.calls {
+		sizes = append(sizes, len(call))
+	}
+	if !reflect.DeepEqual(sizes, []int{10, 10, 5}) {
+		t.Errorf("got calls of %v names, want 10, 10 and 5", sizes)
+	}
+	if len(parameters) != 24 {
+		t.Errorf("got %d parameters, want 24", len(parameters))
+	}
+	if !reflect.DeepEqual(invalid, []string{"/app/MISSING"}) {
+		t.Errorf("got invalid parameters %v, want /app/MISSING", invalid)
+	}
+}
+
+func TestFetchParameterStoreBatch(t *testing.T) {
+	client := &fakeGetParameters{values: map[string]string{
+		"/app/DB_HOST":  "db.internal",
+		"/app/DB_USER":  "app",
+		"/app/API_KEY":  "secret",
+		"/app/LOG_PATH": "/var/log",
+	}}
+
+	tests := []struct {
+		name     string
+		targets  []string
+		want     []map[string]string
+		notFound map[int][]string
+	}{
+		{
+			name:    "names",
+			targets: []string{"/app/DB_HOST", "/app/DB_USER"},
+			want:    []map[string]string{{"DB_HOST": "db.internal"}, {"DB_USER": "app"}},
+		},
+		{
+			name:    "arn",
+			targets: []string{testParameterArnPrefix + "/app/API_KEY"},
+			want:    []map[string]string{{"API_KEY": "secret"}},
+		},
+		{
+			name:    "version selector",
+			targets: []string{"/app/LOG_PATH:1"},
+			want:    []map[string]string{{"LOG_PATH": "/var/log"}},
+		},
+		{
+			name:    "same name twice",
+			targets: []string{"/app/DB_HOST", "/app/DB_USER", "/app/DB_HOST"},
+			want:    []map[string]string{{"DB_HOST": "db.internal"}, {"DB_USER": "app"}, {"DB_HOST": "db.internal"}},
+		},
+		{
+			name:     "invalid names",
+			targets:  []string{"/app/DB_HOST", "/app/NOPE", "/app/LOG_PATH:7", "/app/NOPE"},
+			want:     []map[string]string{{"DB_HOST": "db.internal"}, {}, {}, {}},
+			notFound: map[int][]string{1: {"/app/NOPE"}, 2: {"/app/LOG_PATH:7"}, 3: {"/app/NOPE"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client.calls = nil
+			targets := make([]core.Target, 0)
+			indexes := make([]int, 0)
+			for i, resource := range test.targets {
+				targets = append(targets, core.Target{Resource: resource})
+				indexes = append(indexes, i)
+			}
+			results := make([]map[string]*variable.Variable, len(targets))
+
+			notFound, err := fetchParameterStoreBatch(client, targets, indexes, results)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(client.calls) != 1 {
+				t.Errorf("got %d GetParameters calls, want 1", len(client.calls))
+			}
+			for i := range results {
+				got := make(map[string]string, 0)
+				for key, result := range results[i] {
+					got[key] = result.Value
+				}
+				if !reflect.DeepEqual(got, test.want[i]) {
+					t.Errorf("target %d: got %v, want %v", i, got, test.want[i])
+				}
+			}
+			if test.notFound == nil {
+				test.notFound = map[int][]string{}
+			}
+			if !reflect.DeepEqual(notFound, test.notFound) {
+				t.Errorf("got not found %v, want %v", notFound, test.notFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmTypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// A SSM parameter store path, where each value is a parameter directly below it.
+type parameterStorePath struct {
+	client *ssm.Client
+	path   string
+	opts   WriteOptions
+
+	// Region and profile, for messages.
+	location string
+}
+
+// Create a store for the parameters below a target's path.
+//
+// Wildcard suffixes and trailing slashes are ignored, so "/app/dev",
+// "/app/dev/" and "/app/dev/*" are the same store.
+func newParameterStorePath(target core.Target, opts WriteOptions) (*parameterStorePath, error) {
+	path := strings.TrimSuffix(target.Resource, "**")
+	path = strings.TrimSuffix(path, "*")
+	path = strings.TrimRight(path, "/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	client, err := initSsmClient(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parameterStorePath{client: client, path: path, opts: opts, location: targetLocation(target)}, nil
+}
+
+func (store *parameterStorePath) String() string {
+	return fmt.Sprintf("SSM parameters below %s%s", store.path, store.location)
+}
+
+// The full parameter name for a variable.
+func (store *parameterStorePath) parameterName(name string) string {
+	return strings.TrimSuffix(store.path, "/") + "/" + name
+}
+
+// Read the parameters directly below the path.
+func (store *parameterStorePath) Read() (map[string]string, error) {
+	values := make(map[string]string, 0)
+	nextToken := ""
+
+	for {
+		input := &ssm.GetParametersByPathInput{
+			Path:           aws.String(store.path),
+			Recursive:      aws.Bool(false),
+			WithDecryption: aws.Bool(true),
+			MaxResults:     aws.Int32(ssmBatchSize),
+			NextToken:      aws.String(nextToken),
+		}
+
+		resp, err := store.client.GetParametersByPath(context.TODO(), input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSM parameters below %s, %w", store.path, err)
+		}
+
+		for i := range resp.Parameters {
+			parameter := &resp.Parameters[i]
+			values[parameterKey(parameter, store.path, SsmNaming_Leaf)] = *parameter.Value
+		}
+
+		if resp.NextToken == nil {
+			break
+		}
+		nextToken = *resp.NextToken
+	}
+
+	return values, nil
+}
+
+// Create, update and delete parameters.
+//
+// The type and KMS key only apply to new parameters. Existing parameters
+// keep theirs when overwritten.
+func (store *parameterStorePath) Apply(current map[string]string, changes []variable.Difference) error {
+	tags := make([]ssmTypes.Tag, 0, len(store.opts.Tags))
+	for _, key := range sortedKeys(store.opts.Tags) {
+		tags = append(tags, ssmTypes.Tag{Key: aws.String(key), Value: aws.String(store.opts.Tags[key])})
+	}
+
+	deletes := make([]string, 0)
+
+	for _, change := range changes {
+		name := store.parameterName(change.Name)
+
+		switch change.Change {
+		case variable.Change_Added:
+			input := &ssm.PutParameterInput{
+				Name:  aws.String(name),
+				Value: aws.String(change.New),
+				Type:  ssmTypes.ParameterType(store.opts.ParameterType),
+			}
+			if store.opts.ParameterType == SsmType_SecureString && store.opts.KmsKeyId != "" {
+				input.KeyId = aws.String(store.opts.KmsKeyId)
+			}
+			if len(tags) != 0 {
+				input.Tags = tags
+			}
+			if _, err := store.client.PutParameter(context.TODO(), input); err != nil {
+				return fmt.Errorf("failed to create SSM parameter %s, %w", name, err)
+			}
+
+		case variable.Change_Changed:
+			input := &ssm.PutParameterInput{
+				Name:      aws.String(name),
+				Value:     aws.String(change.New),
+				Overwrite: aws.Bool(true),
+			}
+			if _, err := store.client.PutParameter(context.TODO(), input); err != nil {
+				return fmt.Errorf("failed to update SSM parameter %s, %w", name, err)
+			}
+
+			// Tags can't be set when overwriting a parameter.
+			if len(tags) != 0 {
+				tagInput := &ssm.AddTagsToResourceInput{
+					ResourceId:   aws.String(name),
+					ResourceType: ssmTypes.ResourceTypeForTaggingParameter,
+					Tags:         tags,
+				}
+				if _, err := store.client.AddTagsToResource(context.TODO(), tagInput); err != nil {
+					return fmt.Errorf("failed to tag SSM parameter %s, %w", name, err)
+				}
+			}
+
+		case variable.Change_Removed:
+			deletes = append(deletes, name)
+		}
+
+		core.PrintVerbose(fmt.Sprintf("\n\t%s %s", change.Change, name))
+	}
+
+	// Only 10 parameters can be deleted per call.
+	for start := 0; start < len(deletes); start += ssmBatchSize {
+		end := start + ssmBatchSize
+		if end > len(deletes) {
+			end = len(deletes)
+		}
+
+		input := &ssm.DeleteParametersInput{Names: deletes[start:end]}
+		resp, err := store.client.DeleteParameters(context.TODO(), input)
+		if err != nil {
+			return fmt.Errorf("failed to delete SSM parameters, %w", err)
+		}
+		if len(resp.InvalidParameters) != 0 {
+			return fmt.Errorf("failed to delete SSM parameters %s", strings.Join(resp.InvalidParameters, ", "))
+		}
+	}
+
+	return nil
+}
+
+// The keys of a map, sorted.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Kinds of remote stores that values can be written to.
+const (
+	// One SSM parameter per value, below a path.
+	Store_SsmParameterStore = "ssm"
+	// One Secrets Manager secret, holding a JSON object of all values.
+	Store_SecretsManager = "secretsmanager"
+)
+
+// SSM parameter types that values can be written as.
+const (
+	SsmType_String       = "String"
+	SsmType_SecureString = "SecureString"
+)
+
+// WriteOptions controls how values are written to a store.
+type WriteOptions struct {
+	// SSM parameter type for new parameters.
+	ParameterType string
+
+	// KMS key to encrypt new SecureString parameters and new secrets with.
+	// The AWS managed key is used when empty.
+	KmsKeyId string
+
+	// Tags to set on written parameters and secrets.
+	Tags map[string]string
+}
+
+// Store is a single remote location that values can be read from and written to.
+type Store interface {
+	// Describe the store, for plans and messages.
+	String() string
+
+	// Read the current values in the store, keyed by variable name.
+	Read() (map[string]string, error)
+
+	// Apply changes to the store. Current holds the values last read.
+	Apply(current map[string]string, changes []variable.Difference) error
+}
+
+// NewStore creates a store of a kind for a target.
+func NewStore(kind string, target core.Target, opts WriteOptions) (Store, error) {
+	if opts.ParameterType == "" {
+		opts.ParameterType = SsmType_SecureString
+	}
+	if opts.ParameterType != SsmType_String && opts.ParameterType != SsmType_SecureString {
+		return nil, fmt.Errorf("unsupported SSM parameter type %q", opts.ParameterType)
+	}
+
+	switch kind {
+	case Store_SsmParameterStore:
+		return newParameterStorePath(target, opts)
+	case Store_SecretsManager:
+		return newSecretsManagerSecret(target, opts)
+	}
+	return nil, fmt.Errorf("unsupported store %q", kind)
+}
@@ -0,0 +1,27 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/divergentcodes/labrador/internal/core"
+)
+
+func TestNewStoreErrors(t *testing.T) {
+	target := core.Target{Resource: "/app/dev"}
+
+	if _, err := NewStore("vault", target, WriteOptions{}); err == nil {
+		t.Error("an unsupported store succeeded")
+	}
+	if _, err := NewStore(Store_SsmParameterStore, target, WriteOptions{ParameterType: "StringList"}); err == nil {
+		t.Error("an unsupported parameter type succeeded")
+	}
+}
+
+func TestParameterName(t *testing.T) {
+	for _, path := range []string{"/app/dev", "/app/dev/"} {
+		store := &parameterStorePath{path: path}
+		if got := store.parameterName("DB_HOST"); got != "/app/dev/DB_HOST" {
+			t.Errorf("parameterName below %s = %s", path, got)
+		}
+	}
+}
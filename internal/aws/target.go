@@ -0,0 +1,27 @@
+package aws
+
+import (
+	"fmt"
+
+	"github.com/divergentcodes/labrador/internal/core"
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Apply a target's own rename rules and filters to the variables fetched from it.
+func applyTargetTransforms(variables map[string]*variable.Variable, target core.Target) (map[string]*variable.Variable, error) {
+
+	variables, err := variable.RenameVariables(variables, target.Rename)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rename rules for %s: %w", target.Resource, err)
+	}
+
+	variables, filteredOut, err := variable.FilterVariables(variables, target.KeyFilter, false, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filters for %s: %w", target.Resource, err)
+	}
+	for name, reason := range filteredOut {
+		core.PrintVerbose(fmt.Sprintf("\n\tFiltered out %s from %s: %s", name, target.Resource, reason))
+	}
+
+	return variables, nil
+}
@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/variable"
 )
 
 // InitConfigDefaults intializes the default configuration settings for the program.
@@ -12,7 +15,16 @@ func InitConfigDefaults() {
 	initRootDefaults()
 	initValueStoreDefaults()
 	initOutputTransformOptions()
+	initFilterDefaults()
+	initExportDefaults()
 	initFetchDefaults()
+	initWatchDefaults()
+	initRunDefaults()
+	initRenderDefaults()
+	initDiffDefaults()
+	initPushDefaults()
+	initSyncDefaults()
+	initValidateDefaults()
 }
 
 func InitConfigInstance() {
@@ -24,7 +36,6 @@ func InitConfigInstance() {
 var (
 	OptStr_Config  = "config"
 	OptStr_Debug   = "debug"
-	OptStr_OutJSON = "out-json"
 	OptStr_Quiet   = "quiet"
 	OptStr_Verbose = "verbose"
 )
@@ -32,7 +43,6 @@ var (
 func initRootDefaults() {
 	viper.SetDefault(OptStr_Config, "")
 	viper.SetDefault(OptStr_Debug, false)
-	viper.SetDefault(OptStr_OutJSON, false)
 	viper.SetDefault(OptStr_Quiet, false)
 	viper.SetDefault(OptStr_Verbose, false)
 }
@@ -41,6 +51,7 @@ func initRootDefaults() {
 var (
 	OptStr_AWS_Region            = "aws.region"
 	OptStr_AWS_SsmParameterStore = "aws.ssm_param"
+	OptStr_AWS_SsmNaming         = "aws.ssm_naming"
 	OptStr_AWS_SecretManager     = "aws.sm_secret" //#nosec
 )
 
@@ -49,6 +60,26 @@ var (
 	OptStr_Quote   = "transform.quote"
 	OptStr_ToLower = "transform.lower"
 	OptStr_ToUpper = "transform.upper"
+	OptStr_Rename  = "transform.rename"
+
+	OptStr_NameReplacement = "transform.name_replacement"
+	OptStr_InvalidNames    = "transform.invalid_names"
+)
+
+// Variable filtering configuration options
+var (
+	OptStr_Include = "filter.include"
+	OptStr_Exclude = "filter.exclude"
+)
+
+// Export configuration options
+var (
+	OptStr_Shell = "export.shell"
+)
+
+// Validate configuration options
+var (
+	OptStr_Required = "required"
 )
 
 // Fetch configuration options
@@ -56,11 +87,71 @@ var (
 	OptStr_NoConflict = "no-conflict"
 	OptStr_OutFile    = "outfile.path"
 	OptStr_FileMode   = "outfile.mode"
+	OptStr_Dialect    = "output.dialect"
+	OptStr_Sort       = "output.sort"
+	OptStr_Format     = "output.format"
+	OptStr_Nest       = "output.nest"
+	OptStr_Separator  = "output.separator"
+	OptStr_Outputs    = "outputs"
+
+	OptStr_K8sName        = "output.k8s.name"
+	OptStr_K8sNamespace   = "output.k8s.namespace"
+	OptStr_K8sLabels      = "output.k8s.labels"
+	OptStr_K8sAnnotations = "output.k8s.annotations"
+)
+
+// Watch configuration options
+var (
+	OptStr_Watch         = "watch.enabled"
+	OptStr_WatchInterval = "watch.interval"
+	OptStr_OnChange      = "watch.on_change"
+	OptStr_Signal        = "watch.signal"
+	OptStr_SignalPid     = "watch.signal_pid"
+)
+
+// Run configuration options
+var (
+	OptStr_RestartOnChange = "run.restart_on_change"
+	OptStr_KillTimeout     = "run.kill_timeout"
+	OptStr_RunSignal       = "run.signal"
+)
+
+// Render configuration options
+var (
+	OptStr_Template       = "render.template"
+	OptStr_RenderOutFile  = "render.outfile"
+	OptStr_RenderFileMode = "render.mode"
+)
+
+// Diff configuration options
+var (
+	OptStr_DiffAgainst       = "diff.against"
+	OptStr_DiffAgainstConfig = "diff.against_config"
+	OptStr_DiffValues        = "diff.values"
+)
+
+// Push configuration options
+var (
+	OptStr_PushFrom      = "push.from"
+	OptStr_DryRun        = "push.dry_run"
+	OptStr_Overwrite     = "push.overwrite"
+	OptStr_KmsKeyId      = "push.kms_key_id"
+	OptStr_ParameterType = "push.param_type"
+	OptStr_Tags          = "push.tags"
+)
+
+// Sync configuration options
+var (
+	OptStr_SyncFrom  = "sync.from"
+	OptStr_SyncTo    = "sync.to"
+	OptStr_SyncPrune = "sync.prune"
+	OptStr_SyncApply = "sync.apply"
 )
 
 func initValueStoreDefaults() {
 	viper.SetDefault(OptStr_AWS_Region, nil)
 	viper.SetDefault(OptStr_AWS_SsmParameterStore, nil)
+	viper.SetDefault(OptStr_AWS_SsmNaming, "leaf")
 	viper.SetDefault(OptStr_AWS_SecretManager, nil)
 }
 
@@ -68,12 +159,80 @@ func initOutputTransformOptions() {
 	viper.SetDefault(OptStr_Quote, false)
 	viper.SetDefault(OptStr_ToLower, false)
 	viper.SetDefault(OptStr_ToUpper, false)
+	viper.SetDefault(OptStr_Rename, nil)
+	viper.SetDefault(OptStr_NameReplacement, "_")
+	viper.SetDefault(OptStr_InvalidNames, "sanitize")
+}
+
+func initFilterDefaults() {
+	viper.SetDefault(OptStr_Include, nil)
+	viper.SetDefault(OptStr_Exclude, nil)
+}
+
+func initExportDefaults() {
+	viper.SetDefault(OptStr_Shell, "posix")
 }
 
 func initFetchDefaults() {
 	viper.SetDefault(OptStr_NoConflict, false)
 	viper.SetDefault(OptStr_OutFile, "")
 	viper.SetDefault(OptStr_FileMode, "0600")
+	viper.SetDefault(OptStr_Dialect, "plain")
+	viper.SetDefault(OptStr_Sort, "alpha")
+	viper.SetDefault(OptStr_Format, "env")
+	viper.SetDefault(OptStr_Nest, false)
+	viper.SetDefault(OptStr_Separator, "_")
+	viper.SetDefault(OptStr_Outputs, nil)
+	viper.SetDefault(OptStr_K8sName, "")
+	viper.SetDefault(OptStr_K8sNamespace, "")
+	viper.SetDefault(OptStr_K8sLabels, nil)
+	viper.SetDefault(OptStr_K8sAnnotations, nil)
+}
+
+func initWatchDefaults() {
+	viper.SetDefault(OptStr_Watch, false)
+	viper.SetDefault(OptStr_WatchInterval, "5m")
+	viper.SetDefault(OptStr_OnChange, "")
+	viper.SetDefault(OptStr_Signal, "")
+	viper.SetDefault(OptStr_SignalPid, 0)
+}
+
+func initRunDefaults() {
+	viper.SetDefault(OptStr_RestartOnChange, false)
+	viper.SetDefault(OptStr_KillTimeout, "10s")
+	viper.SetDefault(OptStr_RunSignal, "")
+}
+
+func initRenderDefaults() {
+	viper.SetDefault(OptStr_Template, "")
+	viper.SetDefault(OptStr_RenderOutFile, "")
+	viper.SetDefault(OptStr_RenderFileMode, "0600")
+}
+
+func initDiffDefaults() {
+	viper.SetDefault(OptStr_DiffAgainst, "")
+	viper.SetDefault(OptStr_DiffAgainstConfig, "")
+	viper.SetDefault(OptStr_DiffValues, "redacted")
+}
+
+func initPushDefaults() {
+	viper.SetDefault(OptStr_PushFrom, "")
+	viper.SetDefault(OptStr_DryRun, false)
+	viper.SetDefault(OptStr_Overwrite, false)
+	viper.SetDefault(OptStr_KmsKeyId, "")
+	viper.SetDefault(OptStr_ParameterType, "SecureString")
+	viper.SetDefault(OptStr_Tags, nil)
+}
+
+func initSyncDefaults() {
+	viper.SetDefault(OptStr_SyncFrom, nil)
+	viper.SetDefault(OptStr_SyncTo, nil)
+	viper.SetDefault(OptStr_SyncPrune, false)
+	viper.SetDefault(OptStr_SyncApply, false)
+}
+
+func initValidateDefaults() {
+	viper.SetDefault(OptStr_Required, nil)
 }
 
 // Configuration file instance setup.
@@ -110,6 +269,76 @@ func initConfigFile() {
 	}
 }
 
+// GetRenameRules returns the global variable rename rules.
+func GetRenameRules() (variable.RenameRules, error) {
+	return GetRenameRulesFrom(viper.GetViper())
+}
+
+// GetRenameRulesFrom returns the variable rename rules of a viper instance.
+func GetRenameRulesFrom(v *viper.Viper) (variable.RenameRules, error) {
+	var rules variable.RenameRules
+	if err := v.UnmarshalKey(OptStr_Rename, &rules); err != nil {
+		return rules, fmt.Errorf("invalid %s: %w", OptStr_Rename, err)
+	}
+	return rules, nil
+}
+
+// GetKeyFilter returns the global variable include/exclude filters.
+func GetKeyFilter() variable.KeyFilter {
+	return GetKeyFilterFrom(viper.GetViper())
+}
+
+// GetKeyFilterFrom returns the variable include/exclude filters of a viper instance.
+func GetKeyFilterFrom(v *viper.Viper) variable.KeyFilter {
+	return variable.KeyFilter{
+		Include: v.GetStringSlice(OptStr_Include),
+		Exclude: v.GetStringSlice(OptStr_Exclude),
+	}
+}
+
+// GetRequiredVariables returns the variables that must be present after fetching.
+//
+// Each entry is either a variable name, or a map with a name and a value pattern.
+func GetRequiredVariables() ([]variable.RequiredVariable, error) {
+	required := make([]variable.RequiredVariable, 0)
+
+	items, ok := viper.Get(OptStr_Required).([]interface{})
+	if !ok {
+		for _, name := range viper.GetStringSlice(OptStr_Required) {
+			required = append(required, variable.RequiredVariable{Name: name})
+		}
+		return required, nil
+	}
+
+	for _, item := range items {
+		var requirement variable.RequiredVariable
+		if name, ok := item.(string); ok {
+			requirement.Name = name
+		} else if err := mapstructure.Decode(item, &requirement); err != nil {
+			return nil, fmt.Errorf("invalid %s entry: %w", OptStr_Required, err)
+		}
+		if requirement.Name == "" {
+			return nil, fmt.Errorf("invalid %s entry: missing name", OptStr_Required)
+		}
+		required = append(required, requirement)
+	}
+
+	return required, nil
+}
+
+// ParseKeyValuePairs parses a list of "key=value" strings into a map.
+func ParseKeyValuePairs(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, 0)
+	for _, pair := range pairs {
+		k, v, found := strings.Cut(pair, "=")
+		if !found || k == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected key=value", pair)
+		}
+		result[k] = v
+	}
+	return result, nil
+}
+
 // Environment variable instance setup.
 func initConfigEnv() {
 	// Support equivalent environment variables.
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+func TestGetRequiredVariables(t *testing.T) {
+	setYAML(t, OptStr_Required, `
+- DB_HOST
+- name: DB_PORT
+  pattern: "^[0-9]+$"
+`)
+	required, err := GetRequiredVariables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []variable.RequiredVariable{{Name: "DB_HOST"}, {Name: "DB_PORT", Pattern: "^[0-9]+$"}}
+	if !reflect.DeepEqual(required, want) {
+		t.Errorf("got %+v, want %+v", required, want)
+	}
+
+	// Environment variables give space separated names.
+	viper.Set(OptStr_Required, "DB_HOST DB_PORT")
+	required, err = GetRequiredVariables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(required) != 2 || required[1].Name != "DB_PORT" {
+		t.Errorf("got %+v, want two plain names", required)
+	}
+
+	setYAML(t, OptStr_Required, "- pattern: x")
+	if _, err := GetRequiredVariables(); err == nil {
+		t.Error("an entry without a name succeeded")
+	}
+}
+
+func TestParseKeyValuePairs(t *testing.T) {
+	pairs, err := ParseKeyValuePairs([]string{"a=1", "b=x=y", "c="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"a": "1", "b": "x=y", "c": ""}; !reflect.DeepEqual(pairs, want) {
+		t.Errorf("got %v, want %v", pairs, want)
+	}
+
+	for _, invalid := range []string{"novalue", "=value"} {
+		if _, err := ParseKeyValuePairs([]string{invalid}); err == nil {
+			t.Errorf("ParseKeyValuePairs(%q) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Output is a single artifact written from the fetched variables, along
+// with any options that only apply to that artifact.
+//
+// Options that aren't set fall back to the global settings.
+type Output struct {
+	// Output format, like the --format flag.
+	Format string `mapstructure:"format"`
+
+	// File path to write to. Written to STDOUT when empty.
+	Path string `mapstructure:"path"`
+
+	// File permissions for a newly created file.
+	Mode string `mapstructure:"mode"`
+
+	// Rename rules applied to the variables for this output only,
+	// after the global rename rules.
+	Rename variable.RenameRules `mapstructure:"rename"`
+
+	// Include/exclude filters applied to the variables for this output only,
+	// after the global filters.
+	variable.KeyFilter `mapstructure:",squash"`
+
+	// Formatting overrides.
+	Quote     *bool             `mapstructure:"quote"`
+	Lower     *bool             `mapstructure:"lower"`
+	Upper     *bool             `mapstructure:"upper"`
+	Nest      *bool             `mapstructure:"nest"`
+	Separator string            `mapstructure:"separator"`
+	Dialect   string            `mapstructure:"dialect"`
+	Shell     string            `mapstructure:"shell"`
+	Sort      string            `mapstructure:"sort"`
+	K8s       OutputK8sMetadata `mapstructure:"k8s"`
+}
+
+// OutputK8sMetadata overrides the metadata of a Kubernetes manifest output.
+type OutputK8sMetadata struct {
+	Name        string   `mapstructure:"name"`
+	Namespace   string   `mapstructure:"namespace"`
+	Labels      []string `mapstructure:"labels"`
+	Annotations []string `mapstructure:"annotations"`
+}
+
+// GetOutputs returns the list of outputs configured at a viper key.
+func GetOutputs(key string) ([]Output, error) {
+	outputs := make([]Output, 0)
+
+	items, ok := viper.Get(key).([]interface{})
+	if !ok {
+		if viper.Get(key) == nil {
+			return outputs, nil
+		}
+		return nil, fmt.Errorf("invalid %s: expected a list", key)
+	}
+
+	for i, item := range items {
+		var output Output
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			ErrorUnused:      true,
+			WeaklyTypedInput: true,
+			Result:           &output,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := decoder.Decode(item); err != nil {
+			return nil, fmt.Errorf("invalid %s entry %d: %w", key, i+1, err)
+		}
+		if output.Format == "" {
+			output.Format = variable.Format_Env
+		}
+		outputs = append(outputs, output)
+	}
+
+	return outputs, nil
+}
+
+// ApplyFormatOptions overrides the given formatting options with any set on the output.
+func (output Output) ApplyFormatOptions(opts variable.FormatOptions) (variable.FormatOptions, error) {
+	if output.Quote != nil {
+		opts.Quote = *output.Quote
+	}
+	if output.Lower != nil {
+		opts.Lower = *output.Lower
+	}
+	if output.Upper != nil {
+		opts.Upper = *output.Upper
+	}
+	if output.Nest != nil {
+		opts.Nest = *output.Nest
+	}
+	if output.Separator != "" {
+		opts.Separator = output.Separator
+	}
+	if output.Dialect != "" {
+		opts.Dialect = output.Dialect
+	}
+	if output.Shell != "" {
+		opts.Shell = output.Shell
+	}
+	if output.Sort != "" {
+		opts.Sort = output.Sort
+	}
+
+	if output.K8s.Name != "" {
+		opts.Kubernetes.Name = output.K8s.Name
+	}
+	if output.K8s.Namespace != "" {
+		opts.Kubernetes.Namespace = output.K8s.Namespace
+	}
+	if output.K8s.Labels != nil {
+		labels, err := ParseKeyValuePairs(output.K8s.Labels)
+		if err != nil {
+			return opts, fmt.Errorf("invalid k8s label: %w", err)
+		}
+		opts.Kubernetes.Labels = labels
+	}
+	if output.K8s.Annotations != nil {
+		annotations, err := ParseKeyValuePairs(output.K8s.Annotations)
+		if err != nil {
+			return opts, fmt.Errorf("invalid k8s annotation: %w", err)
+		}
+		opts.Kubernetes.Annotations = annotations
+	}
+
+	return opts, nil
+}
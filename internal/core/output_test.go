@@ -0,0 +1,124 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Set a viper key from YAML, the way a config file would. The key is
+// cleared after the test, so it doesn't leak into other tests.
+func setYAML(t *testing.T, key string, content string) {
+	t.Helper()
+	t.Cleanup(viper.Reset)
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(content), &value); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(key, value)
+}
+
+func TestGetOutputs(t *testing.T) {
+	setYAML(t, "test_outputs", `
+- path: .env
+  dialect: docker
+- format: json
+  path: sidecar.json
+  mode: "0640"
+  nest: false
+  include: ["SIDECAR_*"]
+  rename:
+    strip_prefix: SIDECAR_
+`)
+
+	outputs, err := GetOutputs("test_outputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 {
+		t.Fatalf("got %d outputs, want 2", len(outputs))
+	}
+
+	if outputs[0].Format != variable.Format_Env || outputs[0].Path != ".env" || outputs[0].Dialect != variable.Dialect_Docker {
+		t.Errorf("unexpected first output %+v", outputs[0])
+	}
+
+	second := outputs[1]
+	if second.Format != variable.Format_JSON || second.Mode != "0640" {
+		t.Errorf("unexpected second output %+v", second)
+	}
+	if second.Nest == nil || *second.Nest {
+		t.Errorf("nest is %v, want an explicit false", second.Nest)
+	}
+	if !reflect.DeepEqual(second.Include, []string{"SIDECAR_*"}) || second.Rename.StripPrefix != "SIDECAR_" {
+		t.Errorf("unexpected filters or rename rules %+v", second)
+	}
+}
+
+func TestGetOutputsErrors(t *testing.T) {
+	outputs, err := GetOutputs("test_outputs_unset")
+	if err != nil || len(outputs) != 0 {
+		t.Errorf("unset outputs gave %v, %v, want none", outputs, err)
+	}
+
+	setYAML(t, "test_outputs_map", "format: json")
+	if _, err := GetOutputs("test_outputs_map"); err == nil {
+		t.Error("outputs that aren't a list succeeded")
+	}
+
+	setYAML(t, "test_outputs_typo", "- fromat: json")
+	if _, err := GetOutputs("test_outputs_typo"); err == nil {
+		t.Error("an output with an unknown setting succeeded")
+	}
+}
+
+func TestApplyFormatOptions(t *testing.T) {
+	global := variable.FormatOptions{
+		Quote:     true,
+		Upper:     true,
+		Dialect:   variable.Dialect_Plain,
+		Sort:      variable.Sort_Alpha,
+		Separator: "_",
+		Kubernetes: variable.KubernetesOptions{
+			Name:   "global",
+			Labels: map[string]string{"a": "1"},
+		},
+	}
+
+	// Nothing set keeps the global options.
+	opts, err := Output{}.ApplyFormatOptions(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts, global) {
+		t.Errorf("empty output changed options to %+v", opts)
+	}
+
+	no := false
+	output := Output{
+		Quote:   &no,
+		Dialect: variable.Dialect_Node,
+		K8s:     OutputK8sMetadata{Name: "sidecar", Labels: []string{"b=2"}},
+	}
+	opts, err = output.ApplyFormatOptions(global)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := global
+	want.Quote = false
+	want.Dialect = variable.Dialect_Node
+	want.Kubernetes = variable.KubernetesOptions{Name: "sidecar", Labels: map[string]string{"b": "2"}}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("got options %+v, want %+v", opts, want)
+	}
+
+	output = Output{K8s: OutputK8sMetadata{Annotations: []string{"missing-equals"}}}
+	if _, err := output.ApplyFormatOptions(global); err == nil {
+		t.Error("an invalid annotation succeeded")
+	}
+}
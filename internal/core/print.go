@@ -2,42 +2,58 @@ package core
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/spf13/viper"
 )
 
+// Where messages are printed, STDOUT unless changed with SetMessageOutput.
+var messageOutput io.Writer = os.Stdout
+
+// SetMessageOutput changes where messages are printed, for commands whose
+// STDOUT belongs to something else, like the value printed by get, or the
+// command started by run.
+func SetMessageOutput(w io.Writer) {
+	messageOutput = w
+}
+
 // Always print message, even when --quiet is passed.
 func PrintAlways(message string) {
-	fmt.Print(message)
+	fmt.Fprint(messageOutput, message)
 }
 
 // Always print message, except when --quiet is passed.
 func PrintNormal(message string) {
 	if !viper.GetBool(OptStr_Quiet) {
-		fmt.Print(message)
+		fmt.Fprint(messageOutput, message)
 	}
 }
 
 // Only print message when --verbose or --debug is passed.
 func PrintVerbose(message string) {
 	if viper.GetBool(OptStr_Verbose) || viper.GetBool(OptStr_Debug) {
-		fmt.Print(message)
+		fmt.Fprint(messageOutput, message)
 	}
 }
 
 // Only print message when --debug is passed.
 func PrintDebug(message string) {
 	if viper.GetBool(OptStr_Debug) {
-		fmt.Print(message)
+		fmt.Fprint(messageOutput, message)
 	}
 }
 
+// Always print a warning to STDERR, so it never mixes with formatted output.
+func PrintWarning(message string) {
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+}
+
 // Print message and immediately exit with exitCode.
 func PrintFatal(message string, exitCode int) {
 	if exitCode == 0 {
 		exitCode = 1
 	}
-	fmt.Printf("Error: %s\n", message)
+	fmt.Fprintf(messageOutput, "Error: %s\n", message)
 	os.Exit(exitCode)
 }
@@ -0,0 +1,116 @@
+package core
+
+// Generation of new, commented configuration files.
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConfigScaffold holds the answers used to generate a new configuration file.
+type ConfigScaffold struct {
+	Region    string
+	SsmParams []string
+	SmSecrets []string
+	Format    string
+	OutFile   string
+}
+
+// Render generates a commented configuration file, and checks it against
+// the same schema as the validate command.
+func (scaffold ConfigScaffold) Render() ([]byte, error) {
+	var builder strings.Builder
+	write := func(format string, args ...interface{}) {
+		builder.WriteString(fmt.Sprintf(format, args...))
+	}
+
+	write("# .labrador.yaml - Labrador configuration file.\n")
+	write("#\n")
+	write("# Check it with \"labrador validate\". See .labrador.example.yaml in the\n")
+	write("# Labrador repository for every available setting.\n")
+
+	write("\naws:\n")
+	if scaffold.Region != "" {
+		write("  # Region for every path and secret, unless they set their own.\n")
+		write("  region: %s\n", yamlQuote(scaffold.Region))
+	} else {
+		write("  # Region for every path and secret, unless they set their own.\n")
+		write("  # Falls back to the AWS_REGION environment variable when unset.\n")
+		write("  # region: us-east-1\n")
+	}
+
+	write("\n  # SSM Parameter Store paths. \"/path/*\" fetches every parameter directly\n")
+	write("  # below /path, \"/path/**\" fetches them recursively.\n")
+	if len(scaffold.SsmParams) == 0 {
+		write("  # ssm_param:\n")
+		write("  # - /app/*\n")
+	} else {
+		write("  ssm_param:\n")
+		for _, param := range scaffold.SsmParams {
+			write("  - %s\n", yamlQuote(param))
+		}
+	}
+
+	write("\n  # Secrets Manager secrets holding JSON key/value pairs.\n")
+	if len(scaffold.SmSecrets) == 0 {
+		write("  # sm_secret:\n")
+		write("  # - app/secrets\n")
+	} else {
+		write("  sm_secret:\n")
+		for _, secret := range scaffold.SmSecrets {
+			write("  - %s\n", yamlQuote(secret))
+		}
+	}
+
+	format := scaffold.Format
+	if format == "" {
+		format = "env"
+	}
+	write("\noutput:\n")
+	write("  # Output format: env, export, json, yaml, toml, k8s-secret, k8s-configmap,\n")
+	write("  # github, gitlab, properties, systemd, tfvars-json.\n")
+	write("  format: %s\n", yamlQuote(format))
+
+	write("\noutfile:\n")
+	write("  # File to write fetched values to. Printed to STDOUT when unset.\n")
+	if scaffold.OutFile != "" {
+		write("  path: %s\n", yamlQuote(scaffold.OutFile))
+	} else {
+		write("  # path: .env\n")
+	}
+	write("  # Permissions for a newly created file.\n")
+	write("  mode: \"0600\"\n")
+
+	content := []byte(builder.String())
+
+	problems, err := ValidateConfig(content)
+	if err != nil {
+		return nil, fmt.Errorf("generated an invalid config: %w", err)
+	}
+	if len(problems) != 0 {
+		return nil, fmt.Errorf("generated an invalid config: %s", problems[0])
+	}
+
+	return content, nil
+}
+
+// Strings that YAML reads back as the same plain string. Anything that
+// could be read as a number, boolean or null is quoted.
+var yamlPlainRegex = regexp.MustCompile(`^[A-Za-z/][A-Za-z0-9_./-]*$`)
+
+// Quote a string for YAML, when it isn't a plain safe scalar.
+//
+// JSON strings are valid YAML double quoted strings.
+func yamlQuote(value string) string {
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null":
+	default:
+		if yamlPlainRegex.MatchString(value) {
+			return value
+		}
+	}
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigScaffoldRender(t *testing.T) {
+	scaffold := ConfigScaffold{
+		Region:    "eu-west-1",
+		SsmParams: []string{"/app/*", "/shared/db/PASSWORD"},
+		SmSecrets: []string{"app/prod", "yes"},
+		Format:    "json",
+		OutFile:   "config #1.json",
+	}
+
+	content, err := scaffold.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config struct {
+		Aws struct {
+			Region   string   `yaml:"region"`
+			SsmParam []string `yaml:"ssm_param"`
+			SmSecret []string `yaml:"sm_secret"`
+		} `yaml:"aws"`
+		Output struct {
+			Format string `yaml:"format"`
+		} `yaml:"output"`
+		Outfile struct {
+			Path string `yaml:"path"`
+			Mode string `yaml:"mode"`
+		} `yaml:"outfile"`
+	}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Aws.Region != scaffold.Region {
+		t.Errorf("region is %q, want %q", config.Aws.Region, scaffold.Region)
+	}
+	if !reflect.DeepEqual(config.Aws.SsmParam, scaffold.SsmParams) {
+		t.Errorf("SSM params are %v, want %v", config.Aws.SsmParam, scaffold.SsmParams)
+	}
+	if !reflect.DeepEqual(config.Aws.SmSecret, scaffold.SmSecrets) {
+		t.Errorf("secrets are %v, want %v", config.Aws.SmSecret, scaffold.SmSecrets)
+	}
+	if config.Output.Format != "json" || config.Outfile.Path != scaffold.OutFile || config.Outfile.Mode != "0600" {
+		t.Errorf("unexpected output settings %+v, %+v", config.Output, config.Outfile)
+	}
+}
+
+func TestConfigScaffoldRenderEmpty(t *testing.T) {
+	content, err := ConfigScaffold{}.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config map[string]map[string]interface{}
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config["output"]["format"] != "env" {
+		t.Errorf("format is %v, want env by default", config["output"]["format"])
+	}
+	if _, ok := config["aws"]["ssm_param"]; ok {
+		t.Error("an empty scaffold declares SSM params")
+	}
+}
+
+func TestConfigScaffoldRenderInvalid(t *testing.T) {
+	if _, err := (ConfigScaffold{Format: "xml"}).Render(); err == nil {
+		t.Error("rendering an unsupported format succeeded")
+	}
+}
+
+func TestYamlQuote(t *testing.T) {
+	tests := map[string]string{
+		"/app/*":        `"/app/*"`,
+		"/app/db":       "/app/db",
+		"app/prod":      "app/prod",
+		"us-east-1":     "us-east-1",
+		"yes":           `"yes"`,
+		"Null":          `"Null"`,
+		"0600":          `"0600"`,
+		"":              `""`,
+		`say "hi": now`: `"say \"hi\": now"`,
+	}
+	for value, want := range tests {
+		if got := yamlQuote(value); got != want {
+			t.Errorf("yamlQuote(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+package core
+
+// Schema of the configuration file, to catch typos that viper ignores.
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// ConfigProblem is a single problem found in a configuration file.
+type ConfigProblem struct {
+	// Line in the file, or 0 if unknown.
+	Line int
+
+	// Dotted path to the setting, like "aws.ssm_param[1].region".
+	Path string
+
+	Message string
+}
+
+func (problem ConfigProblem) String() string {
+	if problem.Line == 0 {
+		return fmt.Sprintf("%s: %s", problem.Path, problem.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", problem.Line, problem.Path, problem.Message)
+}
+
+// Kinds of values in the configuration schema.
+const (
+	schemaString = iota
+	schemaBool
+	schemaInt
+	schemaStrings
+	schemaMap
+	schemaList
+	// A plain string, or a map with the node's fields.
+	schemaStringOrMap
+)
+
+// A node in the configuration schema.
+type schemaNode struct {
+	kind int
+
+	// Allowed values for strings.
+	enum []string
+
+	// Pattern that strings must match, and what it means.
+	pattern     *regexp.Regexp
+	patternHint string
+
+	// Known fields of maps.
+	fields map[string]*schemaNode
+
+	// Schema of list items.
+	items *schemaNode
+}
+
+func schemaStringNode(enum ...string) *schemaNode {
+	return &schemaNode{kind: schemaString, enum: enum}
+}
+
+func schemaBoolNode() *schemaNode {
+	return &schemaNode{kind: schemaBool}
+}
+
+func schemaIntNode() *schemaNode {
+	return &schemaNode{kind: schemaInt}
+}
+
+func schemaStringsNode() *schemaNode {
+	return &schemaNode{kind: schemaStrings}
+}
+
+func schemaMapNode(fields map[string]*schemaNode) *schemaNode {
+	return &schemaNode{kind: schemaMap, fields: fields}
+}
+
+func schemaListNode(items *schemaNode) *schemaNode {
+	return &schemaNode{kind: schemaList, items: items}
+}
+
+// File modes have to be quoted, or YAML reads them as numbers.
+func schemaModeNode() *schemaNode {
+	return &schemaNode{
+		kind:        schemaString,
+		pattern:     regexp.MustCompile(`^0?[0-7]{3}$`),
+		patternHint: `a quoted octal file mode, like "0600"`,
+	}
+}
+
+// Durations, like "30s" or "5m".
+func schemaDurationNode() *schemaNode {
+	return &schemaNode{
+		kind:        schemaString,
+		pattern:     regexp.MustCompile(`^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`),
+		patternHint: `a duration, like "5m"`,
+	}
+}
+
+// "key=value" entries.
+func schemaKeyValuesNode() *schemaNode {
+	return &schemaNode{
+		kind:        schemaStrings,
+		pattern:     regexp.MustCompile(`^[^=]+=`),
+		patternHint: "key=value",
+	}
+}
+
+// Supported values of enumerated settings, shared with the commands that use them.
+var (
+	schemaFormats = []string{
+		variable.Format_Env, variable.Format_Export, variable.Format_JSON, variable.Format_YAML,
+		variable.Format_TOML, variable.Format_KubernetesSecret, variable.Format_KubernetesConfigMap,
+		variable.Format_Github, variable.Format_Gitlab, variable.Format_Properties,
+		variable.Format_Systemd, variable.Format_TfvarsJSON,
+	}
+	schemaDialects = []string{variable.Dialect_Plain, variable.Dialect_Docker, variable.Dialect_Node, variable.Dialect_Python}
+	schemaSorts    = []string{variable.Sort_Alpha, variable.Sort_Source}
+	schemaShells   = []string{
+		variable.Shell_Posix, variable.Shell_Bash, variable.Shell_Zsh,
+		variable.Shell_Fish, variable.Shell_PowerShell, variable.Shell_Cmd,
+	}
+	schemaSsmNamings   = []string{"leaf", "relative"}
+	schemaInvalidNames = []string{variable.InvalidNames_Sanitize, variable.InvalidNames_Skip, variable.InvalidNames_Error}
+)
+
+func schemaRenameNode() *schemaNode {
+	return schemaMapNode(map[string]*schemaNode{
+		"map": schemaListNode(schemaMapNode(map[string]*schemaNode{
+			"from": schemaStringNode(),
+			"to":   schemaStringNode(),
+		})),
+		"replace": schemaListNode(schemaMapNode(map[string]*schemaNode{
+			"pattern": schemaStringNode(),
+			"with":    schemaStringNode(),
+		})),
+		"strip_prefix": schemaStringNode(),
+		"add_prefix":   schemaStringNode(),
+	})
+}
+
+func schemaTargetFields() map[string]*schemaNode {
+	return map[string]*schemaNode{
+		"resource": schemaStringNode(),
+		"path":     schemaStringNode(),
+		"name":     schemaStringNode(),
+		"region":   schemaStringNode(),
+		"profile":  schemaStringNode(),
+		"naming":   schemaStringNode(schemaSsmNamings...),
+		"filters": schemaListNode(schemaMapNode(map[string]*schemaNode{
+			"key":    schemaStringNode(),
+			"option": schemaStringNode("Equals", "BeginsWith", "NotEquals"),
+			"values": schemaStringsNode(),
+		})),
+		"rename":  schemaRenameNode(),
+		"include": schemaStringsNode(),
+		"exclude": schemaStringsNode(),
+	}
+}
+
+// Targets are lists of plain strings, or maps with per-target options.
+func schemaTargetsNode() *schemaNode {
+	return schemaListNode(&schemaNode{kind: schemaStringOrMap, fields: schemaTargetFields()})
+}
+
+func schemaK8sNode() *schemaNode {
+	return schemaMapNode(map[string]*schemaNode{
+		"name":        schemaStringNode(),
+		"namespace":   schemaStringNode(),
+		"labels":      schemaKeyValuesNode(),
+		"annotations": schemaKeyValuesNode(),
+	})
+}
+
+func schemaStoreTargetNode() *schemaNode {
+	fields := schemaTargetFields()
+	fields["store"] = schemaStringNode("ssm", "secretsmanager")
+	return &schemaNode{kind: schemaStringOrMap, fields: fields}
+}
+
+// The schema of the whole configuration file.
+func configSchema() *schemaNode {
+	return schemaMapNode(map[string]*schemaNode{
+		"debug":       schemaBoolNode(),
+		"quiet":       schemaBoolNode(),
+		"verbose":     schemaBoolNode(),
+		"no-conflict": schemaBoolNode(),
+		"aws": schemaMapNode(map[string]*schemaNode{
+			"region":     schemaStringNode(),
+			"ssm_param":  schemaTargetsNode(),
+			"ssm_naming": schemaStringNode(schemaSsmNamings...),
+			"sm_secret":  schemaTargetsNode(),
+		}),
+		"transform": schemaMapNode(map[string]*schemaNode{
+			"quote":            schemaBoolNode(),
+			"lower":            schemaBoolNode(),
+			"upper":            schemaBoolNode(),
+			"rename":           schemaRenameNode(),
+			"name_replacement": schemaStringNode(),
+			"invalid_names":    schemaStringNode(schemaInvalidNames...),
+		}),
+		"filter": schemaMapNode(map[string]*schemaNode{
+			"include": schemaStringsNode(),
+			"exclude": schemaStringsNode(),
+		}),
+		"export": schemaMapNode(map[string]*schemaNode{
+			"shell": schemaStringNode(schemaShells...),
+		}),
+		"outfile": schemaMapNode(map[string]*schemaNode{
+			"path": schemaStringNode(),
+			"mode": schemaModeNode(),
+		}),
+		"output": schemaMapNode(map[string]*schemaNode{
+			"dialect":   schemaStringNode(schemaDialects...),
+			"sort":      schemaStringNode(schemaSorts...),
+			"format":    schemaStringNode(schemaFormats...),
+			"nest":      schemaBoolNode(),
+			"separator": schemaStringNode(),
+			"k8s":       schemaK8sNode(),
+		}),
+		"outputs": schemaListNode(schemaMapNode(map[string]*schemaNode{
+			"format":    schemaStringNode(schemaFormats...),
+			"path":      schemaStringNode(),
+			"mode":      schemaModeNode(),
+			"rename":    schemaRenameNode(),
+			"include":   schemaStringsNode(),
+			"exclude":   schemaStringsNode(),
+			"quote":     schemaBoolNode(),
+			"lower":     schemaBoolNode(),
+			"upper":     schemaBoolNode(),
+			"nest":      schemaBoolNode(),
+			"separator": schemaStringNode(),
+			"dialect":   schemaStringNode(schemaDialects...),
+			"shell":     schemaStringNode(schemaShells...),
+			"sort":      schemaStringNode(schemaSorts...),
+			"k8s":       schemaK8sNode(),
+		})),
+		"watch": schemaMapNode(map[string]*schemaNode{
+			"enabled":    schemaBoolNode(),
+			"interval":   schemaDurationNode(),
+			"on_change":  schemaStringNode(),
+			"signal":     schemaStringNode(),
+			"signal_pid": schemaIntNode(),
+		}),
+		"run": schemaMapNode(map[string]*schemaNode{
+			"restart_on_change": schemaBoolNode(),
+			"kill_timeout":      schemaDurationNode(),
+			"signal":            schemaStringNode(),
+		}),
+		"render": schemaMapNode(map[string]*schemaNode{
+			"template": schemaStringNode(),
+			"outfile":  schemaStringNode(),
+			"mode":     schemaModeNode(),
+		}),
+		"diff": schemaMapNode(map[string]*schemaNode{
+			"against":        schemaStringNode(),
+			"against_config": schemaStringNode(),
+			"values":         schemaStringNode(variable.DiffValues_Redacted, variable.DiffValues_Hash, variable.DiffValues_Plain),
+		}),
+		"push": schemaMapNode(map[string]*schemaNode{
+			"from":       schemaStringNode(),
+			"dry_run":    schemaBoolNode(),
+			"overwrite":  schemaBoolNode(),
+			"kms_key_id": schemaStringNode(),
+			"param_type": schemaStringNode("String", "SecureString"),
+			"tags":       schemaKeyValuesNode(),
+		}),
+		"sync": schemaMapNode(map[string]*schemaNode{
+			"from":  schemaStoreTargetNode(),
+			"to":    schemaStoreTargetNode(),
+			"prune": schemaBoolNode(),
+			"apply": schemaBoolNode(),
+		}),
+		"required": schemaListNode(&schemaNode{
+			kind: schemaStringOrMap,
+			fields: map[string]*schemaNode{
+				"name":    schemaStringNode(),
+				"pattern": schemaStringNode(),
+			},
+		}),
+	})
+}
+
+// ValidateConfig checks the content of a configuration file against the
+// schema, and returns every problem found, in file order.
+func ValidateConfig(content []byte) ([]ConfigProblem, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	problems := make([]ConfigProblem, 0)
+	if len(document.Content) == 0 {
+		return problems, nil
+	}
+
+	validateConfigNode(document.Content[0], configSchema(), "", &problems)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems, nil
+}
+
+// Check a YAML node against its schema, recursively.
+func validateConfigNode(node *yaml.Node, schema *schemaNode, path string, problems *[]ConfigProblem) {
+	report := func(format string, args ...interface{}) {
+		*problems = append(*problems, ConfigProblem{Line: node.Line, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	// Empty values fall back to the defaults.
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	switch schema.kind {
+	case schemaString:
+		if node.Kind != yaml.ScalarNode {
+			report("expected a string")
+			return
+		}
+		validateConfigString(node, schema, report)
+
+	case schemaBool:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			report("expected true or false")
+		}
+
+	case schemaInt:
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			report("expected a whole number")
+		}
+
+	case schemaStrings:
+		// A single string is read as a list of one.
+		if node.Kind == yaml.ScalarNode {
+			validateConfigString(node, schema, report)
+			return
+		}
+		if node.Kind != yaml.SequenceNode {
+			report("expected a list of strings")
+			return
+		}
+		for i, item := range node.Content {
+			validateConfigNode(item, &schemaNode{kind: schemaString, pattern: schema.pattern, patternHint: schema.patternHint},
+				fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+
+	case schemaList:
+		if node.Kind != yaml.SequenceNode {
+			report("expected a list")
+			return
+		}
+		for i, item := range node.Content {
+			validateConfigNode(item, schema.items, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+
+	case schemaStringOrMap:
+		if node.Kind == yaml.ScalarNode {
+			return
+		}
+		validateConfigMap(node, schema, path, problems, report)
+
+	case schemaMap:
+		validateConfigMap(node, schema, path, problems, report)
+	}
+}
+
+// Check a scalar against the allowed values or pattern of a string node.
+func validateConfigString(node *yaml.Node, schema *schemaNode, report func(string, ...interface{})) {
+	if schema.pattern != nil && node.Tag != "!!str" {
+		report("expected %s", schema.patternHint)
+		return
+	}
+	if schema.pattern != nil && !schema.pattern.MatchString(node.Value) {
+		report("%q is not %s", node.Value, schema.patternHint)
+		return
+	}
+	if len(schema.enum) == 0 {
+		return
+	}
+	for _, allowed := range schema.enum {
+		if node.Value == allowed {
+			return
+		}
+	}
+	report("%q is not one of %s", node.Value, strings.Join(schema.enum, ", "))
+}
+
+// Check the keys and values of a map node.
+func validateConfigMap(node *yaml.Node, schema *schemaNode, path string, problems *[]ConfigProblem, report func(string, ...interface{})) {
+	if node.Kind != yaml.MappingNode {
+		report("expected a map")
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+
+		field, known := schema.fields[key]
+		if !known {
+			message := "unknown setting"
+			if suggestion := suggestConfigKey(key, schema.fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			*problems = append(*problems, ConfigProblem{Line: keyNode.Line, Path: childPath, Message: message})
+			continue
+		}
+
+		validateConfigNode(valueNode, field, childPath, problems)
+	}
+}
+
+// Suggest the known key closest to an unknown one, if any is close enough.
+func suggestConfigKey(key string, fields map[string]*schemaNode) string {
+	best := ""
+	bestDistance := 3
+	for candidate := range fields {
+		distance := editDistance(strings.ToLower(key), candidate)
+		if distance < bestDistance || (distance == bestDistance && best != "" && candidate < best) {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Levenshtein distance between two strings.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
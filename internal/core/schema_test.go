@@ -0,0 +1,142 @@
+package core
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// Settings that are only set on the command line, not in a config file.
+var cliOnlySettings = map[string]bool{
+	OptStr_Config: true,
+}
+
+// Every setting with a default needs a schema node of the same kind, or
+// the schema drifts from the config and valid files get reported.
+func TestSchemaCoversConfigDefaults(t *testing.T) {
+	InitConfigDefaults()
+	schema := configSchema()
+
+	for _, key := range viper.AllKeys() {
+		if cliOnlySettings[key] {
+			continue
+		}
+
+		node := schema
+		for _, part := range strings.Split(key, ".") {
+			if node.fields == nil {
+				node = nil
+				break
+			}
+			node = node.fields[part]
+			if node == nil {
+				break
+			}
+		}
+		if node == nil {
+			t.Errorf("%s has no schema node", key)
+			continue
+		}
+
+		switch value := viper.Get(key); value.(type) {
+		case nil:
+			// No default, so any kind of value is fine.
+		case bool:
+			if node.kind != schemaBool {
+				t.Errorf("%s defaults to a bool, but its schema node isn't one", key)
+			}
+		case int:
+			if node.kind != schemaInt {
+				t.Errorf("%s defaults to an int, but its schema node isn't one", key)
+			}
+		case string:
+			if node.kind != schemaString && node.kind != schemaStringOrMap {
+				t.Errorf("%s defaults to a string, but its schema node isn't one", key)
+			}
+		default:
+			t.Errorf("%s has a default of unexpected type %s", key, reflect.TypeOf(value))
+		}
+	}
+}
+
+func TestValidateExampleConfig(t *testing.T) {
+	content, err := os.ReadFile("../../.labrador.example.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := ValidateConfig(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Error(problem)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "empty",
+			content: "",
+			want:    []string{},
+		},
+		{
+			name:    "unknown setting",
+			content: "transform:\n  uper: true\n",
+			want:    []string{`line 2: transform.uper: unknown setting, did you mean "upper"?`},
+		},
+		{
+			name:    "wrong kinds",
+			content: "quiet: yes please\ntransform:\n  upper: 1\nwatch:\n  signal_pid: abc\n  interval: soon\n",
+			want: []string{
+				"line 1: quiet: expected true or false",
+				"line 3: transform.upper: expected true or false",
+				"line 5: watch.signal_pid: expected a whole number",
+				`line 6: watch.interval: "soon" is not a duration, like "5m"`,
+			},
+		},
+		{
+			name:    "enum",
+			content: "output:\n  format: xml\n",
+			want:    []string{`line 2: output.format: "xml" is not one of env, export, json, yaml, toml, k8s-secret, k8s-configmap, github, gitlab, properties, systemd, tfvars-json`},
+		},
+		{
+			name:    "unquoted file mode",
+			content: "outfile:\n  mode: 0600\n",
+			want:    []string{`line 2: outfile.mode: expected a quoted octal file mode, like "0600"`},
+		},
+		{
+			name:    "list items",
+			content: "aws:\n  ssm_param:\n  - /app/*\n  - path: /app/**\n    nameing: relative\n",
+			want:    []string{`line 5: aws.ssm_param[1].nameing: unknown setting, did you mean "naming"?`},
+		},
+		{
+			name:    "null values use defaults",
+			content: "outfile:\n  mode:\nwatch:\n  signal_pid:\n",
+			want:    []string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems, err := ValidateConfig([]byte(test.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(problems))
+			for _, problem := range problems {
+				got = append(got, problem.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+
+	"github.com/divergentcodes/labrador/internal/variable"
+)
+
+// Target is a single remote resource to fetch values from, along with
+// any options that only apply to that resource.
+//
+// Targets are declared either as plain strings (just the resource), or
+// as maps with a "path"/"name" entry for the resource and extra options.
+type Target struct {
+	// Remote resource identifier (SSM parameter path, secret name).
+	Resource string `mapstructure:"resource"`
+
+	// AWS region to fetch from. Falls back to the global region when empty.
+	Region string `mapstructure:"region"`
+
+	// AWS shared config profile to authenticate with.
+	Profile string `mapstructure:"profile"`
+
+	// How variable names are derived from SSM parameter paths.
+	// Falls back to the global setting when empty.
+	Naming string `mapstructure:"naming"`
+
+	// Only fetch SSM parameters from a wildcard path that match all filters.
+	Filters []ParameterFilter `mapstructure:"filters"`
+
+	// Rename rules applied to the variables fetched from this target,
+	// before the global rename rules.
+	Rename variable.RenameRules `mapstructure:"rename"`
+
+	// Include/exclude filters applied to the variables fetched from this
+	// target, before the global filters.
+	variable.KeyFilter `mapstructure:",squash"`
+}
+
+// ParameterFilter narrows down the SSM parameters fetched from a wildcard path.
+type ParameterFilter struct {
+	// Parameter attribute to compare: Type, KeyId, Label, Tier, DataType,
+	// Name, or tag:<tag-key>.
+	Key string `mapstructure:"key"`
+
+	// Comparison: Equals (default), BeginsWith, or NotEquals.
+	Option string `mapstructure:"option"`
+
+	// The attribute matches if it compares true to any of these values.
+	Values []string `mapstructure:"values"`
+}
+
+// Keys that can be used in place of "resource" in a target map.
+var targetResourceAliases = []string{"path", "name"}
+
+// GetTargets returns the list of targets configured at a viper key.
+func GetTargets(key string) ([]Target, error) {
+	return GetTargetsFrom(viper.GetViper(), key)
+}
+
+// GetTargetsFrom returns the list of targets configured at a key of a
+// viper instance, like one holding another config file.
+func GetTargetsFrom(v *viper.Viper, key string) ([]Target, error) {
+	targets := make([]Target, 0)
+
+	switch items := v.Get(key).(type) {
+	case nil:
+		return targets, nil
+	case []interface{}:
+		for _, item := range items {
+			target, err := parseTarget(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s entry: %w", key, err)
+			}
+			targets = append(targets, target)
+		}
+	default:
+		// CLI flags and environment variables are lists of plain strings.
+		for _, resource := range cast.ToStringSlice(items) {
+			targets = append(targets, Target{Resource: resource})
+		}
+	}
+
+	return targets, nil
+}
+
+// Convert a single configured target entry to a Target.
+func parseTarget(item interface{}) (Target, error) {
+	var target Target
+
+	switch value := item.(type) {
+	case string:
+		target.Resource = value
+	case map[string]interface{}:
+		options := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			options[k] = v
+		}
+		for _, alias := range targetResourceAliases {
+			if resource, ok := options[alias]; ok {
+				options["resource"] = resource
+				delete(options, alias)
+			}
+		}
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			ErrorUnused:      true,
+			WeaklyTypedInput: true,
+			Result:           &target,
+		})
+		if err != nil {
+			return target, err
+		}
+		if err := decoder.Decode(options); err != nil {
+			return target, err
+		}
+	default:
+		return target, fmt.Errorf("unsupported value %v", item)
+	}
+
+	if target.Resource == "" {
+		return target, fmt.Errorf("missing path or name")
+	}
+
+	return target, nil
+}
+
+// GetStoreTarget returns the store kind and target configured at a viper key.
+//
+// Store targets are declared either as "kind:resource" strings, like
+// "ssm:/app/dev", or as target maps with an extra "store" entry for the kind.
+func GetStoreTarget(key string) (string, Target, error) {
+	switch value := viper.Get(key).(type) {
+	case nil:
+		return "", Target{}, fmt.Errorf("%s is required", key)
+	case map[string]interface{}:
+		options := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			options[k] = v
+		}
+		kind := cast.ToString(options["store"])
+		delete(options, "store")
+		if kind == "" {
+			return "", Target{}, fmt.Errorf("invalid %s: missing store", key)
+		}
+		target, err := parseTarget(options)
+		if err != nil {
+			return "", Target{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		return kind, target, nil
+	default:
+		if cast.ToString(value) == "" {
+			return "", Target{}, fmt.Errorf("%s is required", key)
+		}
+		kind, resource, found := strings.Cut(cast.ToString(value), ":")
+		if !found || kind == "" || resource == "" {
+			return "", Target{}, fmt.Errorf("invalid %s %q, expected store:resource", key, cast.ToString(value))
+		}
+		return kind, Target{Resource: resource}, nil
+	}
+}
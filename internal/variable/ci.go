@@ -0,0 +1,117 @@
+package variable
+
+// CI provider output formats.
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Format a set of variables for the GitHub Actions $GITHUB_ENV file.
+//
+// Multi-line values use the heredoc delimiter syntax, with a random
+// delimiter that doesn't appear in the value.
+func VariablesAsGithubEnv(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+
+	for _, name := range names {
+		envVarName := formatEnvName(name, opts.Lower, opts.Upper)
+		value := variables[name].Value
+
+		if !strings.ContainsAny(value, "\r\n") {
+			result += fmt.Sprintf("%s=%s\n", envVarName, value)
+			continue
+		}
+
+		delimiter, err := heredocDelimiter(value)
+		if err != nil {
+			return "", err
+		}
+		result += fmt.Sprintf("%s<<%s\n%s\n%s\n", envVarName, delimiter, value, delimiter)
+	}
+
+	// The trailing newline is kept, since later steps append to the same file.
+	return result, nil
+}
+
+// Generate a random heredoc delimiter that doesn't appear in the value.
+func heredocDelimiter(value string) (string, error) {
+	for {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			return "", fmt.Errorf("failed to generate a delimiter: %w", err)
+		}
+
+		delimiter := "ghadelimiter_" + hex.EncodeToString(random)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+// GithubMasks returns the GitHub Actions workflow commands that mask the
+// values of secret variables in logs.
+//
+// Variables are secret when they come from AWS Secrets Manager, or are
+// SecureString SSM parameters. Multi-line values are masked line by line.
+func GithubMasks(variables map[string]*Variable) string {
+	names, _ := SortedNames(variables, Sort_Alpha)
+
+	result := ""
+
+	for _, name := range names {
+		item := variables[name]
+		if !IsSecret(item) {
+			continue
+		}
+
+		for _, line := range strings.Split(strings.ReplaceAll(item.Value, "\r\n", "\n"), "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			escaped := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(line)
+			result += fmt.Sprintf("::add-mask::%s\n", escaped)
+		}
+	}
+
+	return result
+}
+
+// IsSecret reports whether a variable holds a secret value.
+func IsSecret(item *Variable) bool {
+	return item.Source == Source_AwsSecretsManager || item.Metadata["type"] == "SecureString"
+}
+
+// Format a set of variables as a GitLab CI dotenv report artifact.
+//
+// GitLab doesn't support multi-line values in dotenv reports, so those are rejected.
+func VariablesAsGitlabDotenv(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+
+	for _, name := range names {
+		envVarName := formatEnvName(name, opts.Lower, opts.Upper)
+		value := variables[name].Value
+
+		if strings.ContainsAny(value, "\r\n") {
+			return "", fmt.Errorf("%s has a multi-line value, which GitLab dotenv reports don't support", envVarName)
+		}
+		result += fmt.Sprintf("%s=%s\n", envVarName, value)
+	}
+	result = strings.TrimSuffix(result, "\n")
+
+	return result, nil
+}
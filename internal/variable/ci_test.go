@@ -0,0 +1,72 @@
+package variable
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGithubEnv(t *testing.T) {
+	variables := testVariables("SINGLE", "MULTI")
+	variables["SINGLE"].Value = "one line"
+	variables["MULTI"].Value = "first\nsecond"
+
+	output, err := VariablesAsGithubEnv(variables, FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pattern := regexp.MustCompile(`^MULTI<<(ghadelimiter_[0-9a-f]{32})\nfirst\nsecond\n(ghadelimiter_[0-9a-f]{32})\nSINGLE=one line\n$`)
+	match := pattern.FindStringSubmatch(output)
+	if match == nil {
+		t.Fatalf("unexpected output:\n%s", output)
+	}
+	if match[1] != match[2] {
+		t.Errorf("heredoc opened with %s, but closed with %s", match[1], match[2])
+	}
+}
+
+func TestHeredocDelimiterIsUnique(t *testing.T) {
+	first, err := heredocDelimiter("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := heredocDelimiter(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("delimiter %s was reused", first)
+	}
+}
+
+func TestGithubMasks(t *testing.T) {
+	variables := map[string]*Variable{
+		"PLAIN":   {Value: "public", Source: Source_AwsSsmParameterStore, Metadata: map[string]string{"type": "String"}},
+		"SECURE":  {Value: "p%ss", Source: Source_AwsSsmParameterStore, Metadata: map[string]string{"type": "SecureString"}},
+		"SECRET":  {Value: "line one\r\n\r\nline\rtwo\n", Source: Source_AwsSecretsManager, Metadata: map[string]string{}},
+		"UNKNOWN": {Value: "other", Metadata: map[string]string{}},
+	}
+
+	want := "::add-mask::line one\n::add-mask::line%0Dtwo\n::add-mask::p%25ss\n"
+	if got := GithubMasks(variables); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGitlabDotenv(t *testing.T) {
+	output, err := VariablesAsGitlabDotenv(testVariables("B", "A"), FormatOptions{Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a=value of A\nb=value of B"; output != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+
+	variables := testVariables("MULTI")
+	variables["MULTI"].Value = "first\r\nsecond"
+	_, err = VariablesAsGitlabDotenv(variables, FormatOptions{})
+	if err == nil || !strings.Contains(err.Error(), "multi-line") {
+		t.Errorf("got error %v, want a multi-line value error", err)
+	}
+}
@@ -0,0 +1,138 @@
+package variable
+
+// Differences between two sets of variable values.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of difference between two sets of values.
+const (
+	Change_Added   = "added"
+	Change_Removed = "removed"
+	Change_Changed = "changed"
+)
+
+// How values are shown in a diff.
+const (
+	// Values are never shown.
+	DiffValues_Redacted = "redacted"
+	// A short SHA-256 hash of each value, to compare without revealing it.
+	DiffValues_Hash = "hash"
+	// Values are shown in plain text.
+	DiffValues_Plain = "plain"
+)
+
+// Difference is a single name whose value differs between two sets of values.
+type Difference struct {
+	Name   string
+	Change string
+	// Value in the set being compared against. Empty when added.
+	Old string
+	// Value in the current set. Empty when removed.
+	New string
+}
+
+// VariableValues returns the values of a set of variables, keyed by their
+// names as formatted in output.
+func VariableValues(variables map[string]*Variable, opts FormatOptions) map[string]string {
+	values := make(map[string]string, len(variables))
+	for name, item := range variables {
+		values[formatEnvName(name, opts.Lower, opts.Upper)] = item.Value
+	}
+	return values
+}
+
+// VariableVersions returns the remote version of each variable, from its
+// ARN and its "version" (SSM) or "version-id" (Secrets Manager) metadata.
+//
+// Comparing versions finds rotated values without comparing the values.
+func VariableVersions(variables map[string]*Variable) map[string]string {
+	versions := make(map[string]string, len(variables))
+	for name, item := range variables {
+		version := item.Metadata["version"]
+		if version == "" {
+			version = item.Metadata["version-id"]
+		}
+		versions[name] = item.Metadata["arn"] + "@" + version
+	}
+	return versions
+}
+
+// DiffValues compares the current values against another set, sorted by name.
+//
+// Names only in the current set are added, and names only in the other set are removed.
+func DiffValues(current map[string]string, against map[string]string) []Difference {
+	differences := make([]Difference, 0)
+
+	for name, value := range current {
+		old, exists := against[name]
+		switch {
+		case !exists:
+			differences = append(differences, Difference{Name: name, Change: Change_Added, New: value})
+		case old != value:
+			differences = append(differences, Difference{Name: name, Change: Change_Changed, Old: old, New: value})
+		}
+	}
+	for name, old := range against {
+		if _, exists := current[name]; !exists {
+			differences = append(differences, Difference{Name: name, Change: Change_Removed, Old: old})
+		}
+	}
+
+	sort.Slice(differences, func(i, j int) bool {
+		return differences[i].Name < differences[j].Name
+	})
+
+	return differences
+}
+
+// FormatDiff formats a list of differences, one per line, showing values
+// in the given mode. Added lines start with "+", removed with "-", and
+// changed with "~".
+func FormatDiff(differences []Difference, valueMode string) (string, error) {
+	switch valueMode {
+	case DiffValues_Redacted, "", DiffValues_Hash, DiffValues_Plain:
+	default:
+		return "", fmt.Errorf("unsupported diff value mode %q", valueMode)
+	}
+
+	result := ""
+
+	for _, difference := range differences {
+		switch difference.Change {
+		case Change_Added:
+			result += fmt.Sprintf("+ %s = %s\n", difference.Name, diffValue(difference.New, valueMode))
+		case Change_Removed:
+			result += fmt.Sprintf("- %s = %s\n", difference.Name, diffValue(difference.Old, valueMode))
+		case Change_Changed:
+			if valueMode == DiffValues_Redacted || valueMode == "" {
+				result += fmt.Sprintf("~ %s (value changed)\n", difference.Name)
+			} else {
+				result += fmt.Sprintf("~ %s: %s -> %s\n", difference.Name,
+					diffValue(difference.Old, valueMode), diffValue(difference.New, valueMode))
+			}
+		}
+	}
+	result = strings.TrimSuffix(result, "\n")
+
+	return result, nil
+}
+
+// Show a value in a diff.
+func diffValue(value string, valueMode string) string {
+	switch valueMode {
+	case DiffValues_Hash:
+		sum := sha256.Sum256([]byte(value))
+		return "sha256:" + hex.EncodeToString(sum[:])[:16]
+	case DiffValues_Plain:
+		return strconv.Quote(value)
+	default:
+		return "<redacted>"
+	}
+}
@@ -0,0 +1,84 @@
+package variable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVariableValues(t *testing.T) {
+	values := VariableValues(testVariables("db_host", "PORT"), FormatOptions{Upper: true})
+	want := map[string]string{"DB_HOST": "value of db_host", "PORT": "value of PORT"}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %v, want %v", values, want)
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	current := map[string]string{"ADDED": "new", "CHANGED": "2", "SAME": "x"}
+	against := map[string]string{"CHANGED": "1", "REMOVED": "old", "SAME": "x"}
+
+	want := []Difference{
+		{Name: "ADDED", Change: Change_Added, New: "new"},
+		{Name: "CHANGED", Change: Change_Changed, Old: "1", New: "2"},
+		{Name: "REMOVED", Change: Change_Removed, Old: "old"},
+	}
+	if got := DiffValues(current, against); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := DiffValues(current, current); len(got) != 0 {
+		t.Errorf("identical values have differences %+v", got)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	differences := []Difference{
+		{Name: "ADDED", Change: Change_Added, New: "new"},
+		{Name: "CHANGED", Change: Change_Changed, Old: "1", New: "2\n"},
+		{Name: "REMOVED", Change: Change_Removed, Old: "old"},
+	}
+
+	tests := map[string]string{
+		DiffValues_Redacted: "+ ADDED = <redacted>\n~ CHANGED (value changed)\n- REMOVED = <redacted>",
+		DiffValues_Hash: "+ ADDED = sha256:11507a0e2f5e69d5\n" +
+			"~ CHANGED: sha256:6b86b273ff34fce1 -> sha256:53c234e5e8472b6a\n" +
+			"- REMOVED = sha256:cba06b5736faf67e",
+		DiffValues_Plain: "+ ADDED = \"new\"\n~ CHANGED: \"1\" -> \"2\\n\"\n- REMOVED = \"old\"",
+	}
+	for mode, want := range tests {
+		got, err := FormatDiff(differences, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s diff:\n%s\nwant:\n%s", mode, got, want)
+		}
+	}
+
+	if _, err := FormatDiff(differences, "base64"); err == nil {
+		t.Error("an unknown value mode succeeded")
+	}
+}
+
+func TestVariableVersions(t *testing.T) {
+	variables := map[string]*Variable{
+		"PARAM":  {Metadata: map[string]string{"arn": "arn:param", "version": "3"}},
+		"SECRET": {Metadata: map[string]string{"arn": "arn:secret", "version-id": "abc"}},
+		"LOCAL":  {Metadata: map[string]string{}},
+	}
+	want := map[string]string{"PARAM": "arn:param@3", "SECRET": "arn:secret@abc", "LOCAL": "@"}
+	if got := VariableVersions(variables); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// A rotated value shows up as a changed version, without comparing values.
+	rotated := map[string]*Variable{
+		"PARAM":  {Metadata: map[string]string{"arn": "arn:param", "version": "4"}},
+		"SECRET": variables["SECRET"],
+		"LOCAL":  variables["LOCAL"],
+	}
+	differences := DiffValues(VariableVersions(rotated), VariableVersions(variables))
+	if len(differences) != 1 || differences[0].Name != "PARAM" || differences[0].Change != Change_Changed {
+		t.Errorf("got differences %+v, want PARAM changed", differences)
+	}
+}
@@ -0,0 +1,256 @@
+package variable
+
+// Dotenv file dialects, and a parser to read them back.
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Supported dotenv dialects.
+const (
+	// Raw KEY=value lines, or double quoted values with --quote.
+	Dialect_Plain = "plain"
+	// Docker Compose env_file.
+	Dialect_Docker = "docker"
+	// The Node.js dotenv package.
+	Dialect_Node = "node"
+	// The Python python-dotenv package.
+	Dialect_Python = "python"
+)
+
+// Values made of these characters never need quoting, in any dialect.
+var dotenvSafeValueRegex = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// Format a value for an env file in a dialect.
+//
+// Values are left unquoted when safe, unless quote is set. Otherwise each
+// dialect uses the quoting that reads back exactly the original value.
+func dotenvValue(value string, dialect string, quote bool) (string, error) {
+	switch dialect {
+	case Dialect_Plain, "":
+		if quote {
+			// Backslashes are escaped too, or one before the closing quote would escape it.
+			replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+			return "\"" + replacer.Replace(value) + "\"", nil
+		}
+		return value, nil
+	case Dialect_Docker, Dialect_Node, Dialect_Python:
+	default:
+		return "", fmt.Errorf("unsupported dotenv dialect %q", dialect)
+	}
+
+	if !quote && dotenvSafeValueRegex.MatchString(value) {
+		return value, nil
+	}
+
+	switch dialect {
+	case Dialect_Docker:
+		// Single quoted values are literal, but a trailing backslash would
+		// escape the closing quote. Double quoted values are unescaped and
+		// interpolated, where $$ is a literal $.
+		if !strings.Contains(value, "'") && !strings.HasSuffix(value, `\`) {
+			return "'" + value + "'", nil
+		}
+		replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", "$$")
+		return "\"" + replacer.Replace(value) + "\"", nil
+
+	case Dialect_Node:
+		// No escapes are supported, except \n and \r in double quotes,
+		// so pick a quote character that doesn't appear in the value.
+		if !strings.Contains(value, "'") {
+			return "'" + value + "'", nil
+		}
+		if !strings.Contains(value, "`") {
+			return "`" + value + "`", nil
+		}
+		if !strings.Contains(value, `"`) && !strings.Contains(value, `\n`) && !strings.Contains(value, `\r`) {
+			return "\"" + value + "\"", nil
+		}
+		return "", fmt.Errorf("value can't be quoted for node dotenv")
+
+	default:
+		// Single quoted values are literal, except for \\ and \'.
+		replacer := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+		return "'" + replacer.Replace(value) + "'", nil
+	}
+}
+
+// ParseEnvFile parses the content of an env file written in a dialect.
+//
+// Blank lines, comments and "export " prefixes are ignored. Quoted values
+// can span multiple lines.
+func ParseEnvFile(content string, dialect string) (map[string]string, error) {
+	switch dialect {
+	case Dialect_Plain, "", Dialect_Docker, Dialect_Node, Dialect_Python:
+	default:
+		return nil, fmt.Errorf("unsupported dotenv dialect %q", dialect)
+	}
+
+	result := make(map[string]string, 0)
+	// CRLF line endings are trimmed as whitespace, but kept inside quoted
+	// values, where they may be part of the value.
+	lineNumber := 0
+
+	for len(content) > 0 {
+		lineNumber++
+
+		// Take the next line.
+		line := content
+		rest := ""
+		if i := strings.IndexByte(content, '\n'); i >= 0 {
+			line = content[:i]
+			rest = content[i+1:]
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			content = rest
+			continue
+		}
+
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+		separator := strings.IndexByte(trimmed, '=')
+		if separator <= 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNumber)
+		}
+		key := strings.TrimSpace(trimmed[:separator])
+		value := strings.TrimLeft(trimmed[separator+1:], " \t")
+
+		// Quoted values continue until the closing quote, across lines.
+		if len(value) > 0 && strings.ContainsRune(dotenvQuotes(dialect), rune(value[0])) {
+			start := len(strings.TrimRightFunc(line, unicode.IsSpace)) - len(value)
+			end := findClosingQuote(content[start:], dialect)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value for %s", lineNumber, key)
+			}
+			quoted := content[start : start+end+1]
+			lineNumber += strings.Count(quoted, "\n")
+
+			result[key] = unquoteDotenvValue(quoted, dialect)
+
+			// Skip the remainder of the closing line (whitespace or a comment).
+			content = content[start+end+1:]
+			if i := strings.IndexByte(content, '\n'); i >= 0 {
+				content = content[i+1:]
+			} else {
+				content = ""
+			}
+			continue
+		}
+
+		if dialect != Dialect_Plain && dialect != "" {
+			value = stripInlineComment(value, dialect)
+		}
+		result[key] = strings.TrimSpace(value)
+		content = rest
+	}
+
+	return result, nil
+}
+
+// Characters that start a quoted value in a dialect.
+func dotenvQuotes(dialect string) string {
+	switch dialect {
+	case Dialect_Plain, "":
+		return `"`
+	case Dialect_Node:
+		return "'\"`"
+	default:
+		return `'"`
+	}
+}
+
+// Find the index of the quote closing the quoted value at the start of s.
+func findClosingQuote(s string, dialect string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"' && dialect != Dialect_Node:
+			// Skip the escaped character.
+			i++
+		case s[i] == '\\' && quote == '\'' && dialect == Dialect_Python:
+			i++
+		case s[i] == quote && quote == '\'' && dialect == Dialect_Docker && s[i-1] == '\\':
+			// Docker doesn't end single quoted values on an escaped quote.
+			continue
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// Remove the quotes around a value, and decode the dialect's escapes.
+func unquoteDotenvValue(quoted string, dialect string) string {
+	quote := quoted[0]
+	value := quoted[1 : len(quoted)-1]
+
+	switch {
+	case dialect == Dialect_Plain || dialect == "":
+		return unescapeBackslashes(value, map[byte]string{'\\': `\`, '"': `"`}, false)
+
+	case dialect == Dialect_Docker && quote == '"':
+		value = unescapeBackslashes(value, map[byte]string{'n': "\n", 'r': "\r"}, true)
+		return strings.ReplaceAll(value, "$$", "$")
+
+	case dialect == Dialect_Node && quote == '"':
+		return strings.NewReplacer(`\n`, "\n", `\r`, "\r").Replace(value)
+
+	case dialect == Dialect_Python && quote == '\'':
+		return unescapeBackslashes(value, map[byte]string{'\\': `\`, '\'': "'"}, false)
+
+	case dialect == Dialect_Python && quote == '"':
+		escapes := map[byte]string{
+			'\\': `\`, '\'': "'", '"': `"`, 'a': "\a", 'b': "\b",
+			'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+		}
+		return unescapeBackslashes(value, escapes, false)
+	}
+
+	return value
+}
+
+// Decode backslash escapes. Unknown escapes are kept as-is, or reduced
+// to the escaped character when dropUnknown is set.
+func unescapeBackslashes(value string, escapes map[byte]string, dropUnknown bool) string {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			result.WriteByte(value[i])
+			continue
+		}
+
+		i++
+		if decoded, ok := escapes[value[i]]; ok {
+			result.WriteString(decoded)
+		} else if dropUnknown {
+			result.WriteByte(value[i])
+		} else {
+			result.WriteByte('\\')
+			result.WriteByte(value[i])
+		}
+	}
+	return result.String()
+}
+
+// Remove a trailing comment from an unquoted value.
+//
+// Node dotenv treats any # as a comment, the others need whitespace before it.
+func stripInlineComment(value string, dialect string) string {
+	if dialect == Dialect_Node {
+		if i := strings.IndexByte(value, '#'); i >= 0 {
+			return value[:i]
+		}
+		return value
+	}
+
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return value[:i]
+		}
+	}
+	return value
+}
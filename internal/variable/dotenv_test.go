@@ -0,0 +1,173 @@
+package variable
+
+import (
+	"strings"
+	"testing"
+)
+
+// Values that are hard to quote, for round trip tests.
+var dotenvTestValues = []string{
+	"",
+	"plain",
+	"with spaces",
+	`back\slash`,
+	`trailing\`,
+	`\\double`,
+	`C:\path\to\file`,
+	"$HOME ${USER} $$",
+	"a # not a comment",
+	"#leading",
+	`double "quotes"`,
+	"single 'quotes'",
+	"it's \"both\"",
+	"`backticks`",
+	`\"escaped\"`,
+	`'\'`,
+	"two\nlines",
+	"trailing newline\n",
+	"\n\nleading newlines",
+	"carriage\r\nreturn",
+	`literal \n escape`,
+	"tab\tseparated",
+	"  padded  ",
+	"unicode ✓ é",
+}
+
+func TestDotenvRoundTrip(t *testing.T) {
+	for _, dialect := range []string{Dialect_Plain, Dialect_Docker, Dialect_Node, Dialect_Python} {
+		for _, quote := range []bool{false, true} {
+			// Plain unquoted lines are written raw, so they don't round trip.
+			if dialect == Dialect_Plain && !quote {
+				continue
+			}
+
+			for _, value := range dotenvTestValues {
+				formatted, err := dotenvValue(value, dialect, quote)
+				if err != nil {
+					// Node dotenv can't quote every value, which must be an error.
+					if dialect == Dialect_Node {
+						continue
+					}
+					t.Errorf("%s (quote %t): can't format %q: %s", dialect, quote, value, err)
+					continue
+				}
+
+				content := "BEFORE=1\nKEY=" + formatted + "\nAFTER=2\n"
+				parsed, err := ParseEnvFile(content, dialect)
+				if err != nil {
+					t.Errorf("%s (quote %t): can't parse %q formatted as %q: %s", dialect, quote, value, formatted, err)
+					continue
+				}
+				if parsed["KEY"] != value {
+					t.Errorf("%s (quote %t): %q formatted as %q reads back as %q", dialect, quote, value, formatted, parsed["KEY"])
+				}
+				if parsed["BEFORE"] != "1" || parsed["AFTER"] != "2" {
+					t.Errorf("%s (quote %t): %q formatted as %q breaks the lines around it: %v", dialect, quote, value, formatted, parsed)
+				}
+			}
+		}
+	}
+}
+
+func TestDotenvNodeUnquotable(t *testing.T) {
+	if _, err := dotenvValue("'`\"", Dialect_Node, false); err == nil {
+		t.Error("a value with every node quote character was formatted")
+	}
+}
+
+func TestDotenvPlainUnquoted(t *testing.T) {
+	for _, value := range []string{"plain", `back\slash`, `trailing\`, "$HOME", "a # b", `mid "quotes"`} {
+		formatted, err := dotenvValue(value, Dialect_Plain, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted != value {
+			t.Errorf("%q formatted as %q, want it unchanged", value, formatted)
+		}
+		parsed, err := ParseEnvFile("KEY="+formatted, Dialect_Plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed["KEY"] != value {
+			t.Errorf("%q reads back as %q", value, parsed["KEY"])
+		}
+	}
+}
+
+func TestDotenvUnsafeValuesAreQuoted(t *testing.T) {
+	for _, dialect := range []string{Dialect_Docker, Dialect_Node, Dialect_Python} {
+		for _, value := range []string{"a b", "$HOME", "a#b", "x\ny"} {
+			formatted, err := dotenvValue(value, dialect, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.ContainsAny(formatted[:1], "'\"`") {
+				t.Errorf("%s: %q formatted as %q, want it quoted", dialect, value, formatted)
+			}
+		}
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	content := strings.Join([]string{
+		"# comment",
+		"",
+		"export EXPORTED=yes",
+		"SPACED = value  ",
+		"INLINE=value # comment",
+		"QUOTED='single' # comment",
+		"MULTI=\"first",
+		"second\"",
+		"EMPTY=",
+	}, "\n")
+
+	parsed, err := ParseEnvFile(content, Dialect_Python)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"EXPORTED": "yes",
+		"SPACED":   "value",
+		"INLINE":   "value",
+		"QUOTED":   "single",
+		"MULTI":    "first\nsecond",
+		"EMPTY":    "",
+	}
+	for key, value := range want {
+		if parsed[key] != value {
+			t.Errorf("%s = %q, want %q", key, parsed[key], value)
+		}
+	}
+	if len(parsed) != len(want) {
+		t.Errorf("parsed %d values, want %d: %v", len(parsed), len(want), parsed)
+	}
+}
+
+func TestParseEnvFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing separator": "NOT A PAIR",
+		"missing key":       "=value",
+		"unterminated":      "KEY=\"open\nstill open",
+	}
+	for name, content := range tests {
+		if _, err := ParseEnvFile(content, Dialect_Docker); err == nil {
+			t.Errorf("%s: parsed %q without an error", name, content)
+		}
+	}
+	if _, err := ParseEnvFile("A=1", "unknown"); err == nil {
+		t.Error("parsed with an unknown dialect")
+	}
+}
+
+func TestParseEnvFileCRLF(t *testing.T) {
+	parsed, err := ParseEnvFile("A=1\r\nB='two'\r\n# comment\r\nC=\"three\" # comment\r\n", Dialect_Docker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"A": "1", "B": "two", "C": "three"}
+	for key, value := range want {
+		if parsed[key] != value {
+			t.Errorf("%s = %q, want %q", key, parsed[key], value)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package variable
+
+// Include/exclude filters for fetched variables.
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Prefix that marks a filter pattern as a regular expression instead of a glob.
+const RegexPatternPrefix = "re:"
+
+// KeyFilter keeps or drops variables by their name, source path, or ARN.
+//
+// Patterns are globs (e.g. "DB_*", "/app/*/secret"), or regular expressions
+// when prefixed with "re:" (e.g. "re:^(DB|CACHE)_").
+type KeyFilter struct {
+	// When not empty, only variables matching at least one pattern are kept.
+	Include []string `mapstructure:"include"`
+
+	// Variables matching any of these patterns are dropped.
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// IsEmpty reports whether the filter would keep every variable.
+func (filter KeyFilter) IsEmpty() bool {
+	return len(filter.Include) == 0 && len(filter.Exclude) == 0
+}
+
+// FilterVariables applies include/exclude filters to a set of variables.
+//
+// Names are matched after lower/upper case folding, the way they are
+// written in output. Returns the kept variables, and the reason each
+// dropped variable was filtered out.
+func FilterVariables(variables map[string]*Variable, filter KeyFilter, lower bool, upper bool) (map[string]*Variable, map[string]string, error) {
+	filteredOut := make(map[string]string, 0)
+	if filter.IsEmpty() {
+		return variables, filteredOut, nil
+	}
+
+	includes, err := compilePatterns(filter.Include)
+	if err != nil {
+		return nil, nil, err
+	}
+	excludes, err := compilePatterns(filter.Exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := make(map[string]*Variable, len(variables))
+	for name, item := range variables {
+		subjects := filterSubjects(foldNameCase(name, lower, upper), item)
+
+		if len(includes) != 0 && matchAnyPattern(includes, subjects) == nil {
+			filteredOut[name] = "not matched by any include pattern"
+			continue
+		}
+		if pattern := matchAnyPattern(excludes, subjects); pattern != nil {
+			filteredOut[name] = fmt.Sprintf("matched exclude pattern %q", pattern.raw)
+			continue
+		}
+
+		result[name] = item
+	}
+
+	return result, filteredOut, nil
+}
+
+// A compiled glob or regex filter pattern.
+type filterPattern struct {
+	raw   string
+	regex *regexp.Regexp
+}
+
+// Check if the pattern matches a string.
+func (pattern *filterPattern) match(subject string) bool {
+	if pattern.regex != nil {
+		return pattern.regex.MatchString(subject)
+	}
+	matched, _ := path.Match(pattern.raw, subject)
+	return matched
+}
+
+// Compile and validate a list of filter patterns.
+func compilePatterns(rawPatterns []string) ([]*filterPattern, error) {
+	patterns := make([]*filterPattern, 0, len(rawPatterns))
+	for _, raw := range rawPatterns {
+		pattern := &filterPattern{raw: raw}
+
+		if strings.HasPrefix(raw, RegexPatternPrefix) {
+			re, err := regexp.Compile(strings.TrimPrefix(raw, RegexPatternPrefix))
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter pattern %q: %w", raw, err)
+			}
+			pattern.regex = re
+		} else if _, err := path.Match(raw, ""); err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %q: %w", raw, err)
+		}
+
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// Return the first pattern that matches any of the subjects, or nil.
+func matchAnyPattern(patterns []*filterPattern, subjects []string) *filterPattern {
+	for _, pattern := range patterns {
+		for _, subject := range subjects {
+			if pattern.match(subject) {
+				return pattern
+			}
+		}
+	}
+	return nil
+}
+
+// The strings a filter is evaluated on: the variable name, and its source path and ARN.
+func filterSubjects(name string, item *Variable) []string {
+	subjects := []string{name}
+	for _, field := range []string{"path", "secret-name", "arn"} {
+		if value, ok := item.Metadata[field]; ok && value != "" {
+			subjects = append(subjects, value)
+		}
+	}
+	return subjects
+}
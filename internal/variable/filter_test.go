@@ -0,0 +1,120 @@
+package variable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterVariables(t *testing.T) {
+	variables := testVariables("DB_HOST", "DB_PASSWORD", "ADMIN_TOKEN", "APP_TEST")
+	variables["DB_HOST"].Metadata["path"] = "/app/db/host"
+	variables["ADMIN_TOKEN"].Metadata["arn"] = "arn:aws:secretsmanager:us-east-1:123456789012:secret:admin"
+
+	tests := []struct {
+		name        string
+		filter      KeyFilter
+		want        []string
+		filteredOut map[string]string
+	}{
+		{
+			name:        "empty filter",
+			want:        []string{"ADMIN_TOKEN", "APP_TEST", "DB_HOST", "DB_PASSWORD"},
+			filteredOut: map[string]string{},
+		},
+		{
+			name:   "include glob",
+			filter: KeyFilter{Include: []string{"DB_*"}},
+			want:   []string{"DB_HOST", "DB_PASSWORD"},
+			filteredOut: map[string]string{
+				"ADMIN_TOKEN": "not matched by any include pattern",
+				"APP_TEST":    "not matched by any include pattern",
+			},
+		},
+		{
+			name:   "exclude regex",
+			filter: KeyFilter{Exclude: []string{"re:_TEST$"}},
+			want:   []string{"ADMIN_TOKEN", "DB_HOST", "DB_PASSWORD"},
+			filteredOut: map[string]string{
+				"APP_TEST": `matched exclude pattern "re:_TEST$"`,
+			},
+		},
+		{
+			name:   "exclude wins over include",
+			filter: KeyFilter{Include: []string{"DB_*"}, Exclude: []string{"*_PASSWORD"}},
+			want:   []string{"DB_HOST"},
+			filteredOut: map[string]string{
+				"ADMIN_TOKEN": "not matched by any include pattern",
+				"APP_TEST":    "not matched by any include pattern",
+				"DB_PASSWORD": `matched exclude pattern "*_PASSWORD"`,
+			},
+		},
+		{
+			name:   "source path",
+			filter: KeyFilter{Include: []string{"/app/db/*"}},
+			want:   []string{"DB_HOST"},
+			filteredOut: map[string]string{
+				"ADMIN_TOKEN": "not matched by any include pattern",
+				"APP_TEST":    "not matched by any include pattern",
+				"DB_PASSWORD": "not matched by any include pattern",
+			},
+		},
+		{
+			name:   "arn",
+			filter: KeyFilter{Exclude: []string{"re::secret:admin$"}},
+			want:   []string{"APP_TEST", "DB_HOST", "DB_PASSWORD"},
+			filteredOut: map[string]string{
+				"ADMIN_TOKEN": `matched exclude pattern "re::secret:admin$"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, filteredOut, err := FilterVariables(variables, test.filter, false, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := variableNames(result); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("kept %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(filteredOut, test.filteredOut) {
+				t.Errorf("filtered out %v, want %v", filteredOut, test.filteredOut)
+			}
+		})
+	}
+}
+
+func TestFilterVariablesCaseFolding(t *testing.T) {
+	variables := testVariables("db_host", "cache_host", "DB_PORT")
+	filter := KeyFilter{Include: []string{"DB_*"}}
+
+	tests := []struct {
+		name  string
+		lower bool
+		upper bool
+		want  []string
+	}{
+		{name: "as fetched", want: []string{"DB_PORT"}},
+		{name: "upper", upper: true, want: []string{"DB_PORT", "db_host"}},
+		{name: "lower", lower: true, want: []string{}},
+	}
+
+	for _, test := range tests {
+		result, _, err := FilterVariables(variables, filter, test.lower, test.upper)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := variableNames(result); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: kept %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestFilterVariablesInvalidPattern(t *testing.T) {
+	for _, pattern := range []string{"re:(", "[a-"} {
+		_, _, err := FilterVariables(testVariables("A"), KeyFilter{Include: []string{pattern}}, false, false)
+		if err == nil {
+			t.Errorf("FilterVariables with pattern %q succeeded, want an error", pattern)
+		}
+	}
+}
@@ -4,27 +4,116 @@ package variable
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 )
 
-// Format a set of variables as an env file.
-func VariablesAsEnvFile(variables map[string]*Variable, quote bool, lower bool, upper bool) (string, error) {
+// Supported output formats.
+const (
+	Format_Env    = "env"
+	Format_Export = "export"
+	Format_JSON   = "json"
+	Format_YAML   = "yaml"
+	Format_TOML   = "toml"
+
+	Format_KubernetesSecret    = "k8s-secret"
+	Format_KubernetesConfigMap = "k8s-configmap"
+
+	Format_Github = "github"
+	Format_Gitlab = "gitlab"
+
+	Format_Properties = "properties"
+	Format_Systemd    = "systemd"
+	Format_TfvarsJSON = "tfvars-json"
+)
+
+// Supported shells for export output.
+const (
+	Shell_Posix      = "posix"
+	Shell_Bash       = "bash"
+	Shell_Zsh        = "zsh"
+	Shell_Fish       = "fish"
+	Shell_PowerShell = "powershell"
+	Shell_Cmd        = "cmd"
+)
+
+// FormatOptions controls how a set of variables is formatted.
+type FormatOptions struct {
+	// Always quote values.
+	Quote bool
+	// Set all variable names to lower case.
+	Lower bool
+	// Set all variable names to upper case.
+	Upper bool
+	// Dotenv dialect for env files.
+	Dialect string
+	// Shell syntax for exports.
+	Shell string
+	// Order of the variables in the output.
+	Sort string
+	// Nest structured output by splitting names on the separator.
+	Nest bool
+	// Separator between nested name segments.
+	Separator string
+	// Metadata for Kubernetes manifests.
+	Kubernetes KubernetesOptions
+}
+
+// Format a set of variables in one of the supported output formats.
+func Format(variables map[string]*Variable, format string, opts FormatOptions) (string, error) {
+	switch format {
+	case Format_Env, "":
+		return VariablesAsEnvFile(variables, opts)
+	case Format_Export:
+		return VariablesAsShellExport(variables, opts)
+	case Format_JSON:
+		return VariablesAsJSON(variables, opts)
+	case Format_YAML:
+		return VariablesAsYAML(variables, opts)
+	case Format_TOML:
+		return VariablesAsTOML(variables, opts)
+	case Format_KubernetesSecret:
+		return VariablesAsKubernetesSecret(variables, opts)
+	case Format_KubernetesConfigMap:
+		return VariablesAsKubernetesConfigMap(variables, opts)
+	case Format_Github:
+		return VariablesAsGithubEnv(variables, opts)
+	case Format_Gitlab:
+		return VariablesAsGitlabDotenv(variables, opts)
+	case Format_Properties:
+		return VariablesAsProperties(variables, opts)
+	case Format_Systemd:
+		return VariablesAsSystemdEnvFile(variables, opts)
+	case Format_TfvarsJSON:
+		return VariablesAsTfvarsJSON(variables, opts)
+	}
+	return "", fmt.Errorf("unsupported output format %q", format)
+}
+
+// IsAppendableFormat reports whether output in a format can be appended to an existing file.
+func IsAppendableFormat(format string) bool {
+	switch format {
+	case Format_Env, Format_Export, Format_Github, Format_Gitlab, Format_Properties, Format_Systemd, "":
+		return true
+	}
+	return false
+}
+
+// Format a set of variables as an env file, in a dotenv dialect.
+func VariablesAsEnvFile(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
 
 	result := ""
 
-	for name, item := range variables {
-		envVarName := envNamify(name)
-		if lower {
-			envVarName = strings.ToLower(envVarName)
-		} else if upper {
-			envVarName = strings.ToUpper(envVarName)
-		}
+	for _, name := range names {
+		envVarName := formatEnvName(name, opts.Lower, opts.Upper)
 
-		envVarValue := item.Value
-		if quote {
-			envVarValue = escapeDoubleQuotes(envVarValue)
-			envVarValue = fmt.Sprintf("\"%s\"", envVarValue)
+		envVarValue, err := dotenvValue(variables[name].Value, opts.Dialect, opts.Quote)
+		if err != nil {
+			return "", fmt.Errorf("can't format %s: %w", envVarName, err)
 		}
 
 		result += fmt.Sprintf("%s=%s\n", envVarName, envVarValue)
@@ -36,31 +125,125 @@ func VariablesAsEnvFile(variables map[string]*Variable, quote bool, lower bool,
 
 // Format a set of shell environment variable exports.
 //
+// Values are always single quoted, so nothing in them is expanded by the shell.
+//
 // source <(labrador export)
-func VariablesAsShellExport(variables map[string]*Variable, lower bool, upper bool) (string, error) {
+func VariablesAsShellExport(variables map[string]*Variable, opts FormatOptions) (string, error) {
 
-	result, err := VariablesAsEnvFile(variables, true, lower, upper)
+	names, err := SortedNames(variables, opts.Sort)
 	if err != nil {
 		return "", err
 	}
 
-	re := regexp.MustCompile(`(?m)^`)
-	result = re.ReplaceAllString(result, "export ")
+	result := ""
+
+	for _, name := range names {
+		item := variables[name]
+		envVarName := formatEnvName(name, opts.Lower, opts.Upper)
+
+		var line string
+		switch opts.Shell {
+		case Shell_Posix, Shell_Bash, Shell_Zsh, "":
+			line = fmt.Sprintf("export %s=%s", envVarName, posixQuote(item.Value))
+		case Shell_Fish:
+			line = fmt.Sprintf("set -gx %s %s", envVarName, fishQuote(item.Value))
+		case Shell_PowerShell:
+			line = fmt.Sprintf("$env:%s = %s", envVarName, powerShellQuote(item.Value))
+		case Shell_Cmd:
+			value, err := cmdEscape(item.Value)
+			if err != nil {
+				return "", fmt.Errorf("can't export %s to cmd.exe: %w", envVarName, err)
+			}
+			line = fmt.Sprintf("set \"%s=%s\"", envVarName, value)
+		default:
+			return "", fmt.Errorf("unsupported shell %q", opts.Shell)
+		}
+
+		result += line + "\n"
+	}
+	result = strings.TrimSuffix(result, "\n")
 
 	return result, nil
 }
 
-// Escape double quotes in provided string.
-func escapeDoubleQuotes(value string) string {
-	envVarValue := strings.Replace(value, "\"", "\\\"", -1)
-	return envVarValue
+// Apply the name transforms shared by the env formats.
+func formatEnvName(name string, lower bool, upper bool) string {
+	return foldNameCase(envNamify(name), lower, upper)
+}
+
+// Apply the lower/upper case option to a name. Lower case wins.
+func foldNameCase(name string, lower bool, upper bool) string {
+	if lower {
+		return strings.ToLower(name)
+	} else if upper {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// LookupVariable finds a variable by its name, or by its name as formatted in output.
+func LookupVariable(variables map[string]*Variable, name string, opts FormatOptions) (*Variable, bool) {
+	if item, ok := variables[name]; ok {
+		return item, true
+	}
+
+	names, _ := SortedNames(variables, Sort_Alpha)
+	for _, candidate := range names {
+		if formatEnvName(candidate, opts.Lower, opts.Upper) == name {
+			return variables[candidate], true
+		}
+	}
+
+	return nil, false
+}
+
+// Single quote a value for POSIX shells, where nothing inside single quotes
+// is special. Each single quote closes the string, adds an escaped quote,
+// and reopens it.
+func posixQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Single quote a value for fish, where only backslashes and single quotes
+// are escaped inside single quotes.
+func fishQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// Single quote a value for PowerShell, where single quotes (including the
+// typographic variants PowerShell also accepts) are escaped by doubling them.
+func powerShellQuote(value string) string {
+	replacer := strings.NewReplacer(
+		"'", "''",
+		"\u2018", "\u2018\u2018",
+		"\u2019", "\u2019\u2019",
+		"\u201a", "\u201a\u201a",
+		"\u201b", "\u201b\u201b",
+	)
+	return "'" + replacer.Replace(value) + "'"
+}
+
+// Escape a value for a quoted cmd.exe batch file assignment.
+//
+// Inside set "NAME=value" only percent signs are expanded, until a double
+// quote ends the quoting and lets &|<>^ chain commands. Exclamation marks
+// expand when delayed expansion is on, which can't be known in advance, and
+// values can't span lines in cmd.exe, so all of those are rejected.
+func cmdEscape(value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("value contains a line break")
+	}
+	if strings.Contains(value, `"`) {
+		return "", fmt.Errorf("value contains a double quote")
+	}
+	if strings.Contains(value, "!") {
+		return "", fmt.Errorf("value contains an exclamation mark, which delayed expansion would expand")
+	}
+	return strings.ReplaceAll(value, "%", "%%"), nil
 }
 
 // Transform strings into valid environment variable names.
 func envNamify(name string) string {
-	envVarName := name
-	for _, c := range [2]string{"-", " "} {
-		envVarName = strings.Replace(envVarName, c, "_", -1)
-	}
-	return envVarName
+	return SanitizeEnvName(name, "_")
 }
@@ -0,0 +1,133 @@
+package variable
+
+import (
+	"testing"
+)
+
+func TestPosixQuote(t *testing.T) {
+	tests := map[string]string{
+		"":             "''",
+		"plain":        "'plain'",
+		"$HOME `id`":   "'$HOME `id`'",
+		"it's":         `'it'\''s'`,
+		"a\\b\nc":      "'a\\b\nc'",
+		"'; rm -rf /'": `''\''; rm -rf /'\'''`,
+	}
+	for value, want := range tests {
+		if got := posixQuote(value); got != want {
+			t.Errorf("posixQuote(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestFishQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":    "'plain'",
+		"$HOME":    "'$HOME'",
+		"it's":     `'it\'s'`,
+		`a\b`:      `'a\\b'`,
+		`trail\`:   `'trail\\'`,
+		"(cmd); x": "'(cmd); x'",
+	}
+	for value, want := range tests {
+		if got := fishQuote(value); got != want {
+			t.Errorf("fishQuote(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestPowerShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "'plain'",
+		"$env:PATH":  "'$env:PATH'",
+		"it's":       "'it''s'",
+		"it’s":       "'it’’s'",
+		"`n $(calc)": "'`n $(calc)'",
+	}
+	for value, want := range tests {
+		if got := powerShellQuote(value); got != want {
+			t.Errorf("powerShellQuote(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestCmdEscape(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: "100%", want: "100%%"},
+		{value: "%PATH%", want: "%%PATH%%"},
+		{value: "a & b | c < d > e ^ f", want: "a & b | c < d > e ^ f"},
+		{value: `x" & calc & "`, wantErr: true},
+		{value: `"`, wantErr: true},
+		{value: "hello!", wantErr: true},
+		{value: "!PATH!", wantErr: true},
+		{value: "two\nlines", wantErr: true},
+		{value: "cr\r", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := cmdEscape(test.value)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("cmdEscape(%q) = %q, want an error", test.value, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("cmdEscape(%q) failed: %s", test.value, err)
+		} else if got != test.want {
+			t.Errorf("cmdEscape(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestVariablesAsShellExportCmd(t *testing.T) {
+	variables := map[string]*Variable{
+		"SAFE": {Key: "SAFE", Value: "50%"},
+	}
+	got, err := VariablesAsShellExport(variables, FormatOptions{Shell: Shell_Cmd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `set "SAFE=50%%"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	variables["EVIL"] = &Variable{Key: "EVIL", Value: `x" & calc & "`}
+	if got, err := VariablesAsShellExport(variables, FormatOptions{Shell: Shell_Cmd}); err == nil {
+		t.Errorf("got %q, want an error for a value with a double quote", got)
+	}
+}
+
+func TestLookupVariable(t *testing.T) {
+	variables := testVariables("DB_HOST", "db.port", "api_key")
+
+	tests := []struct {
+		name  string
+		opts  FormatOptions
+		found string
+	}{
+		{name: "DB_HOST", found: "DB_HOST"},
+		{name: "db.port", found: "db.port"},
+		{name: "db_port", found: "db.port"},
+		{name: "API_KEY", opts: FormatOptions{Upper: true}, found: "api_key"},
+		{name: "db_host", opts: FormatOptions{Lower: true}, found: "DB_HOST"},
+		{name: "db_host"},
+		{name: "MISSING"},
+	}
+	for _, test := range tests {
+		item, ok := LookupVariable(variables, test.name, test.opts)
+		if test.found == "" {
+			if ok {
+				t.Errorf("LookupVariable(%q) found %s, want nothing", test.name, item.Key)
+			}
+			continue
+		}
+		if !ok || item.Key != test.found {
+			t.Errorf("LookupVariable(%q) = %v, %t, want %s", test.name, item, ok, test.found)
+		}
+	}
+}
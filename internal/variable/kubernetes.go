@@ -0,0 +1,92 @@
+package variable
+
+// Kubernetes Secret and ConfigMap manifest output formats.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KubernetesOptions sets the metadata of generated Kubernetes manifests.
+type KubernetesOptions struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// Secret and ConfigMap data keys can only contain these characters.
+var kubernetesKeyRegex = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// Format a set of variables as a Kubernetes Secret manifest, with base64 encoded data.
+func VariablesAsKubernetesSecret(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	return kubernetesManifest(variables, opts, "Secret", func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	})
+}
+
+// Format a set of variables as a Kubernetes ConfigMap manifest.
+func VariablesAsKubernetesConfigMap(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	return kubernetesManifest(variables, opts, "ConfigMap", func(value string) string {
+		return value
+	})
+}
+
+// Build a Kubernetes manifest with the variables as its data.
+func kubernetesManifest(variables map[string]*Variable, opts FormatOptions, kind string, encode func(string) string) (string, error) {
+	if opts.Kubernetes.Name == "" {
+		return "", fmt.Errorf("a name is required for the %s", kind)
+	}
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	metadata := yamlMapping()
+	yamlAppend(metadata, "name", yamlString(opts.Kubernetes.Name))
+	if opts.Kubernetes.Namespace != "" {
+		yamlAppend(metadata, "namespace", yamlString(opts.Kubernetes.Namespace))
+	}
+	if len(opts.Kubernetes.Labels) != 0 {
+		yamlAppend(metadata, "labels", yamlStringMap(opts.Kubernetes.Labels))
+	}
+	if len(opts.Kubernetes.Annotations) != 0 {
+		yamlAppend(metadata, "annotations", yamlStringMap(opts.Kubernetes.Annotations))
+	}
+
+	data := yamlMapping()
+	for _, name := range names {
+		key := formatEnvName(name, opts.Lower, opts.Upper)
+		if !kubernetesKeyRegex.MatchString(key) {
+			return "", fmt.Errorf("%s is not a valid %s key", key, kind)
+		}
+		yamlAppend(data, key, yamlString(encode(variables[name].Value)))
+	}
+
+	manifest := yamlMapping()
+	yamlAppend(manifest, "apiVersion", yamlString("v1"))
+	yamlAppend(manifest, "kind", yamlString(kind))
+	yamlAppend(manifest, "metadata", metadata)
+	if kind == "Secret" {
+		yamlAppend(manifest, "type", yamlString("Opaque"))
+	}
+	yamlAppend(manifest, "data", data)
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(manifest); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
@@ -0,0 +1,77 @@
+package variable
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func kubernetesOptions() FormatOptions {
+	return FormatOptions{
+		Kubernetes: KubernetesOptions{
+			Name:        "app-secrets",
+			Namespace:   "prod",
+			Labels:      map[string]string{"app.kubernetes.io/name": "app", "tier": "backend"},
+			Annotations: map[string]string{"owner": "platform"},
+		},
+	}
+}
+
+func TestKubernetesManifestsGolden(t *testing.T) {
+	secret, err := Format(nestedVariables(), Format_KubernetesSecret, kubernetesOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "k8s-secret.yaml", secret)
+
+	configMap, err := Format(nestedVariables(), Format_KubernetesConfigMap, kubernetesOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "k8s-configmap.yaml", configMap)
+}
+
+func TestKubernetesSecretData(t *testing.T) {
+	output, err := VariablesAsKubernetesSecret(nestedVariables(), kubernetesOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest struct {
+		Data map[string]string `yaml:"data"`
+	}
+	if err := yaml.Unmarshal([]byte(output), &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, item := range nestedVariables() {
+		decoded, err := base64.StdEncoding.DecodeString(manifest.Data[name])
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if string(decoded) != item.Value {
+			t.Errorf("%s decodes to %q, want %q", name, decoded, item.Value)
+		}
+	}
+}
+
+func TestKubernetesManifestMinimal(t *testing.T) {
+	opts := FormatOptions{Kubernetes: KubernetesOptions{Name: "app"}, Lower: true}
+	output, err := VariablesAsKubernetesConfigMap(testVariables("DB_HOST"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata:\n  db_host: value of DB_HOST"
+	if output != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestKubernetesManifestNeedsName(t *testing.T) {
+	for _, format := range []string{Format_KubernetesSecret, Format_KubernetesConfigMap} {
+		if _, err := Format(testVariables("A"), format, FormatOptions{}); err == nil {
+			t.Errorf("%s without a name succeeded", format)
+		}
+	}
+}
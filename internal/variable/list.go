@@ -0,0 +1,52 @@
+package variable
+
+// Metadata listing, without values.
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Format a table of the variables and where each came from, without their values.
+func VariablesAsTable(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tSOURCE\tRESOURCE\tTYPE\tVERSION\tMODIFIED\tARN")
+
+	for _, name := range names {
+		item := variables[name]
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			formatEnvName(name, opts.Lower, opts.Upper),
+			item.Source,
+			tableCell(item.Metadata, "path", "secret-name"),
+			tableCell(item.Metadata, "type"),
+			tableCell(item.Metadata, "version", "version-id"),
+			tableCell(item.Metadata, "last-modified", "created-date"),
+			tableCell(item.Metadata, "arn"),
+		)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// Get the first metadata value set for any of the keys, or "-".
+func tableCell(metadata map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := metadata[key]; value != "" {
+			return value
+		}
+	}
+	return "-"
+}
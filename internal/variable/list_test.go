@@ -0,0 +1,53 @@
+package variable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVariablesAsTable(t *testing.T) {
+	variables := map[string]*Variable{
+		"DB_HOST": {
+			Value:  "db.internal",
+			Source: Source_AwsSsmParameterStore,
+			Metadata: map[string]string{
+				"path":          "/app/db/host",
+				"type":          "String",
+				"version":       "3",
+				"last-modified": "2024-01-02",
+				"arn":           "arn:aws:ssm:us-east-1:123456789012:parameter/app/db/host",
+			},
+		},
+		"DB_PASSWORD": {
+			Value:  "hunter2",
+			Source: Source_AwsSecretsManager,
+			Metadata: map[string]string{
+				"secret-name":  "app/db",
+				"version-id":   "abc123",
+				"created-date": "2024-01-01",
+			},
+		},
+	}
+
+	output, err := VariablesAsTable(variables, FormatOptions{Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "list.txt", output)
+
+	for _, item := range variables {
+		if strings.Contains(output, item.Value) {
+			t.Errorf("table shows the value %q", item.Value)
+		}
+	}
+}
+
+func TestTableCell(t *testing.T) {
+	metadata := map[string]string{"path": "", "secret-name": "app/db"}
+	if got := tableCell(metadata, "path", "secret-name"); got != "app/db" {
+		t.Errorf("got %q, want the first set key", got)
+	}
+	if got := tableCell(metadata, "arn"); got != "-" {
+		t.Errorf("got %q, want - for a missing key", got)
+	}
+}
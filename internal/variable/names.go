@@ -0,0 +1,133 @@
+package variable
+
+// Environment variable name validation and sanitization.
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Policies for variable names that aren't valid environment variable names.
+const (
+	// Replace invalid characters to make the name valid.
+	InvalidNames_Sanitize = "sanitize"
+	// Drop the variable.
+	InvalidNames_Skip = "skip"
+	// Fail with an error.
+	InvalidNames_Error = "error"
+)
+
+// A valid POSIX shell identifier.
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Characters allowed in the replacement for invalid characters.
+var envNameReplacementRegex = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+
+// IsValidEnvName reports whether a name is a valid POSIX shell identifier.
+func IsValidEnvName(name string) bool {
+	return envNameRegex.MatchString(name)
+}
+
+// SanitizeEnvName makes a name a valid POSIX shell identifier.
+//
+// Every character that isn't an ASCII letter, digit or underscore is
+// swapped for the replacement, and a leading digit gets the replacement
+// (or an underscore, if the replacement is empty) as a prefix.
+func SanitizeEnvName(name string, replacement string) string {
+	var sanitized strings.Builder
+	for _, c := range name {
+		if c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			sanitized.WriteRune(c)
+		} else {
+			sanitized.WriteString(replacement)
+		}
+	}
+
+	result := sanitized.String()
+	if result != "" && result[0] >= '0' && result[0] <= '9' {
+		prefix := replacement
+		if prefix == "" || (prefix[0] >= '0' && prefix[0] <= '9') {
+			prefix = "_" + prefix
+		}
+		result = prefix + result
+	}
+
+	return result
+}
+
+// SanitizeVariableNames applies an invalid name policy to a set of variables.
+//
+// Returns the variables with valid names, and the reason each dropped
+// variable was skipped. The error policy fails on the first invalid name.
+//
+// Names are compared after lower/upper case folding, since names that only
+// differ in case would otherwise collide in the output. Valid names win
+// over sanitized ones, and otherwise the first name in sorted order wins.
+func SanitizeVariableNames(variables map[string]*Variable, replacement string, policy string, lower bool, upper bool) (map[string]*Variable, map[string]string, error) {
+	skipped := make(map[string]string, 0)
+
+	if !envNameReplacementRegex.MatchString(replacement) {
+		return nil, nil, fmt.Errorf("invalid name replacement %q: only letters, digits and underscores are allowed", replacement)
+	}
+	if policy != InvalidNames_Sanitize && policy != InvalidNames_Skip && policy != InvalidNames_Error {
+		return nil, nil, fmt.Errorf("unknown invalid name policy %q", policy)
+	}
+
+	// Sorted, so the same variable wins every time when names collide.
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]*Variable, len(variables))
+	// Variable names by their name in output.
+	taken := make(map[string]string, len(variables))
+
+	for _, name := range names {
+		if !IsValidEnvName(name) {
+			continue
+		}
+		folded := formatEnvName(name, lower, upper)
+		if other, exists := taken[folded]; exists {
+			skipped[name] = fmt.Sprintf("name %s is already taken by %s", folded, other)
+			continue
+		}
+		result[name] = variables[name]
+		taken[folded] = name
+	}
+
+	for _, name := range names {
+		if IsValidEnvName(name) {
+			continue
+		}
+
+		switch policy {
+		case InvalidNames_Error:
+			return nil, nil, fmt.Errorf("%q is not a valid environment variable name", name)
+		case InvalidNames_Skip:
+			skipped[name] = "not a valid environment variable name"
+			continue
+		}
+
+		sanitized := SanitizeEnvName(name, replacement)
+		if !IsValidEnvName(sanitized) {
+			skipped[name] = "can't be made a valid environment variable name"
+			continue
+		}
+		folded := formatEnvName(sanitized, lower, upper)
+		if other, exists := taken[folded]; exists {
+			skipped[name] = fmt.Sprintf("sanitized name %s is already taken by %s", folded, other)
+			continue
+		}
+
+		renamed := *variables[name]
+		renamed.Key = sanitized
+		result[sanitized] = &renamed
+		taken[folded] = name
+	}
+
+	return result, skipped, nil
+}
@@ -0,0 +1,143 @@
+package variable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeEnvName(t *testing.T) {
+	tests := []struct {
+		name        string
+		replacement string
+		want        string
+	}{
+		{name: "DB_HOST", replacement: "_", want: "DB_HOST"},
+		{name: "db.host", replacement: "_", want: "db_host"},
+		{name: "db-host/port", replacement: "__", want: "db__host__port"},
+		{name: "db.host", replacement: "", want: "dbhost"},
+		{name: "1PASSWORD", replacement: "_", want: "_1PASSWORD"},
+		{name: "1PASSWORD", replacement: "X", want: "X1PASSWORD"},
+		{name: "1PASSWORD", replacement: "", want: "_1PASSWORD"},
+		{name: "1.A", replacement: "9", want: "_919A"},
+		{name: "ключ", replacement: "_", want: "____"},
+	}
+	for _, test := range tests {
+		if got := SanitizeEnvName(test.name, test.replacement); got != test.want {
+			t.Errorf("SanitizeEnvName(%q, %q) = %q, want %q", test.name, test.replacement, got, test.want)
+		}
+	}
+}
+
+func TestSanitizeVariableNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      []string
+		policy  string
+		lower   bool
+		upper   bool
+		want    []string
+		skipped map[string]string
+	}{
+		{
+			name:    "valid names",
+			in:      []string{"A", "b_2"},
+			policy:  InvalidNames_Sanitize,
+			want:    []string{"A", "b_2"},
+			skipped: map[string]string{},
+		},
+		{
+			name:    "sanitize",
+			in:      []string{"db.host", "2FA"},
+			policy:  InvalidNames_Sanitize,
+			want:    []string{"_2FA", "db_host"},
+			skipped: map[string]string{},
+		},
+		{
+			name:    "skip",
+			in:      []string{"db.host", "DB_PORT"},
+			policy:  InvalidNames_Skip,
+			want:    []string{"DB_PORT"},
+			skipped: map[string]string{"db.host": "not a valid environment variable name"},
+		},
+		{
+			name:    "sanitized name taken by a valid name",
+			in:      []string{"db.host", "db_host"},
+			policy:  InvalidNames_Sanitize,
+			want:    []string{"db_host"},
+			skipped: map[string]string{"db.host": "sanitized name db_host is already taken by db_host"},
+		},
+		{
+			name:    "sanitized names collide",
+			in:      []string{"db-host", "db.host"},
+			policy:  InvalidNames_Sanitize,
+			want:    []string{"db_host"},
+			skipped: map[string]string{"db.host": "sanitized name db_host is already taken by db-host"},
+		},
+		{
+			name:    "upper case collision",
+			in:      []string{"db_host", "DB_HOST"},
+			policy:  InvalidNames_Sanitize,
+			upper:   true,
+			want:    []string{"DB_HOST"},
+			skipped: map[string]string{"db_host": "name DB_HOST is already taken by DB_HOST"},
+		},
+		{
+			name:    "lower case collision with a sanitized name",
+			in:      []string{"DB.HOST", "db_host"},
+			policy:  InvalidNames_Sanitize,
+			lower:   true,
+			want:    []string{"db_host"},
+			skipped: map[string]string{"DB.HOST": "sanitized name db_host is already taken by db_host"},
+		},
+		{
+			name:    "no collision without case folding",
+			in:      []string{"db_host", "DB_HOST"},
+			policy:  InvalidNames_Sanitize,
+			want:    []string{"DB_HOST", "db_host"},
+			skipped: map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, skipped, err := SanitizeVariableNames(testVariables(test.in...), "_", test.policy, test.lower, test.upper)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := variableNames(result); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("kept %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(skipped, test.skipped) {
+				t.Errorf("skipped %v, want %v", skipped, test.skipped)
+			}
+			for name, item := range result {
+				if item.Key != name {
+					t.Errorf("variable %s has key %s", name, item.Key)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeVariableNamesKeepsInput(t *testing.T) {
+	variables := testVariables("db.host")
+	if _, _, err := SanitizeVariableNames(variables, "_", InvalidNames_Sanitize, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if key := variables["db.host"].Key; key != "db.host" {
+		t.Errorf("input variable key changed to %s", key)
+	}
+}
+
+func TestSanitizeVariableNamesErrors(t *testing.T) {
+	variables := testVariables("db.host")
+	if _, _, err := SanitizeVariableNames(variables, "_", InvalidNames_Error, false, false); err == nil {
+		t.Error("error policy succeeded with an invalid name")
+	}
+	if _, _, err := SanitizeVariableNames(variables, "-", InvalidNames_Sanitize, false, false); err == nil {
+		t.Error("succeeded with an invalid replacement")
+	}
+	if _, _, err := SanitizeVariableNames(variables, "_", "ignore", false, false); err == nil {
+		t.Error("succeeded with an unknown policy")
+	}
+}
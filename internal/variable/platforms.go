@@ -0,0 +1,135 @@
+package variable
+
+// Java properties, systemd EnvironmentFile and Terraform tfvars output formats.
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Format a set of variables as a Java .properties file.
+//
+// Keys and values are escaped the way java.util.Properties stores them,
+// including \uXXXX escapes for non-ASCII characters, so the file reads
+// back exactly with either the ISO-8859-1 or the UTF-8 loader.
+func VariablesAsProperties(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+
+	for _, name := range names {
+		key := formatEnvName(name, opts.Lower, opts.Upper)
+		value := variables[name].Value
+		result += fmt.Sprintf("%s=%s\n", propertiesEscape(key, true), propertiesEscape(value, false))
+	}
+	result = strings.TrimSuffix(result, "\n")
+
+	return result, nil
+}
+
+// Escape a properties key or value, like Properties.store does.
+//
+// Spaces are always escaped in keys, but only when leading in values.
+func propertiesEscape(text string, isKey bool) string {
+	var result strings.Builder
+	for i, c := range text {
+		switch c {
+		case ' ':
+			if isKey || i == 0 {
+				result.WriteString(`\ `)
+			} else {
+				result.WriteRune(c)
+			}
+		case '\\', '=', ':', '#', '!':
+			result.WriteRune('\\')
+			result.WriteRune(c)
+		case '\t':
+			result.WriteString(`\t`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\f':
+			result.WriteString(`\f`)
+		default:
+			if c < 0x20 || c > 0x7e {
+				result.WriteString(propertiesUnicodeEscape(c))
+			} else {
+				result.WriteRune(c)
+			}
+		}
+	}
+	return result.String()
+}
+
+// Escape a character as \uXXXX, using a UTF-16 surrogate pair when needed.
+func propertiesUnicodeEscape(c rune) string {
+	if c <= 0xffff {
+		return fmt.Sprintf(`\u%04X`, c)
+	}
+	c -= 0x10000
+	return fmt.Sprintf(`\u%04X\u%04X`, 0xd800+(c>>10), 0xdc00+(c&0x3ff))
+}
+
+// Format a set of variables as a systemd EnvironmentFile.
+//
+// Unsafe values are double quoted, where systemd only unescapes \", \\, \`
+// and \$, and newlines are kept. Values are never expanded by systemd.
+func VariablesAsSystemdEnvFile(variables map[string]*Variable, opts FormatOptions) (string, error) {
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`)
+	result := ""
+
+	for _, name := range names {
+		envVarName := formatEnvName(name, opts.Lower, opts.Upper)
+		if !IsValidEnvName(envVarName) {
+			return "", fmt.Errorf("%s is not a valid systemd environment variable name", envVarName)
+		}
+
+		value := variables[name].Value
+		if opts.Quote || !dotenvSafeValueRegex.MatchString(value) {
+			value = "\"" + replacer.Replace(value) + "\""
+		}
+		result += fmt.Sprintf("%s=%s\n", envVarName, value)
+	}
+	result = strings.TrimSuffix(result, "\n")
+
+	return result, nil
+}
+
+// A valid Terraform variable name.
+var terraformNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Format a set of variables as a terraform.tfvars.json file.
+//
+// With nesting, variables sharing a prefix become a single object variable.
+func VariablesAsTfvarsJSON(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	tree, err := variablesAsTree(variables, opts)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range tree.keys {
+		if !terraformNameRegex.MatchString(key) {
+			return "", fmt.Errorf("%s is not a valid Terraform variable name", key)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := writeJSON(&buffer, tree, ""); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,86 @@
+package variable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPropertiesEscape(t *testing.T) {
+	tests := []struct {
+		text  string
+		isKey bool
+		want  string
+	}{
+		{text: "plain", want: "plain"},
+		{text: " leading and inner space", want: `\ leading and inner space`},
+		{text: "a key", isKey: true, want: `a\ key`},
+		{text: `a=b:c#d!e\f`, want: `a\=b\:c\#d\!e\\f`},
+		{text: "tab\tnewline\nreturn\rfeed\f", want: `tab\tnewline\nreturn\rfeed\f`},
+		{text: "\x01café", want: `\u0001caf\u00E9`},
+		{text: "\U0001F600", want: `\uD83D\uDE00`},
+	}
+	for _, test := range tests {
+		if got := propertiesEscape(test.text, test.isKey); got != test.want {
+			t.Errorf("propertiesEscape(%q, %t) = %s, want %s", test.text, test.isKey, got, test.want)
+		}
+	}
+}
+
+func TestProperties(t *testing.T) {
+	variables := testVariables("DB_URL", "GREETING")
+	variables["DB_URL"].Value = "jdbc:postgresql://db:5432/app"
+	variables["GREETING"].Value = "héllo"
+
+	output, err := VariablesAsProperties(variables, FormatOptions{Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "db_url=jdbc\\:postgresql\\://db\\:5432/app\ngreeting=h\\u00E9llo"
+	if output != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestSystemdEnvFile(t *testing.T) {
+	variables := testVariables("SAFE", "SPACES", "SPECIAL", "MULTI")
+	variables["SAFE"].Value = "db.internal:5432"
+	variables["SPACES"].Value = "two words"
+	variables["SPECIAL"].Value = "$HOME `id` \"q\" \\"
+	variables["MULTI"].Value = "first\nsecond"
+
+	output, err := VariablesAsSystemdEnvFile(variables, FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"MULTI=\"first\nsecond\"",
+		"SAFE=db.internal:5432",
+		`SPACES="two words"`,
+		"SPECIAL=\"\\$HOME \\`id\\` \\\"q\\\" \\\\\"",
+	}, "\n")
+	if output != want {
+		t.Errorf("got:\n%s\nwant:\n%s", output, want)
+	}
+
+	output, err = VariablesAsSystemdEnvFile(testVariables("A"), FormatOptions{Quote: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `A="value of A"`; output != want {
+		t.Errorf("quoted output is %s, want %s", output, want)
+	}
+}
+
+func TestTfvarsJSON(t *testing.T) {
+	output, err := VariablesAsTfvarsJSON(nestedVariables(), FormatOptions{Nest: true, Separator: "_", Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, "tfvars-nested.json", output)
+
+	// Nested segments can start with a digit, which Terraform doesn't allow.
+	_, err = VariablesAsTfvarsJSON(testVariables("_1_A"), FormatOptions{Nest: true, Separator: "_"})
+	if err == nil || !strings.Contains(err.Error(), "not a valid Terraform variable name") {
+		t.Errorf("got error %v, want an invalid name error", err)
+	}
+}
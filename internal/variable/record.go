@@ -1,6 +1,13 @@
 // Package variable is a canonical, intermediate representation of a value from a remote system.
 package variable
 
+// Remote services that variables can come from, in the order they are fetched.
+const (
+	Source_AwsSsmParameterStore = "aws-ssm-parameter-store"
+	Source_AwsSecretsManager    = "aws-secrets-manager"
+)
+
+// Variable is a key/value pair fetched from a remote service.
 type Variable struct {
 	// The key for the variable.
 	Key string
@@ -11,6 +18,9 @@ type Variable struct {
 	// Remote service that the key/value pair came from.
 	Source string
 
+	// Position of the target the variable was fetched from, in declared order.
+	TargetIndex int
+
 	// Additional attributes about this variable that might be useful.
 	Metadata map[string]string
 }
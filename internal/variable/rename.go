@@ -0,0 +1,121 @@
+package variable
+
+// Rules for renaming variables before they are formatted.
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RenameRules declares how variable names are rewritten.
+//
+// An explicit mapping takes precedence over all other rules. Otherwise,
+// regex replacements are applied in order, then the prefix is stripped,
+// and then the new prefix is added.
+type RenameRules struct {
+	// Explicit name mappings.
+	Map []RenameMapping `mapstructure:"map"`
+
+	// Regex replacements, applied in order.
+	Replace []RenameReplacement `mapstructure:"replace"`
+
+	// Prefix to remove from names that have it.
+	StripPrefix string `mapstructure:"strip_prefix"`
+
+	// Prefix to add to every name.
+	AddPrefix string `mapstructure:"add_prefix"`
+}
+
+// RenameMapping renames one variable to another name.
+type RenameMapping struct {
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+}
+
+// RenameReplacement replaces all regex matches in a name.
+type RenameReplacement struct {
+	// Regular expression to match (RE2 syntax).
+	Pattern string `mapstructure:"pattern"`
+
+	// Replacement text, which can reference capture groups like ${1}.
+	With string `mapstructure:"with"`
+}
+
+// IsEmpty reports whether the rules would leave every name unchanged.
+func (rules RenameRules) IsEmpty() bool {
+	return len(rules.Map) == 0 && len(rules.Replace) == 0 && rules.StripPrefix == "" && rules.AddPrefix == ""
+}
+
+// RenameVariables applies rename rules to a set of variables.
+//
+// Two variables ending up with the same name is an error, since either one
+// would silently replace the other.
+func RenameVariables(variables map[string]*Variable, rules RenameRules) (map[string]*Variable, error) {
+	if rules.IsEmpty() {
+		return variables, nil
+	}
+
+	mappings := make(map[string]string, len(rules.Map))
+	for _, mapping := range rules.Map {
+		if mapping.From == "" || mapping.To == "" {
+			return nil, fmt.Errorf("rename mappings need both from and to")
+		}
+		mappings[mapping.From] = mapping.To
+	}
+
+	patterns := make([]*regexp.Regexp, len(rules.Replace))
+	for i, replacement := range rules.Replace {
+		re, err := regexp.Compile(replacement.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rename pattern %q: %w", replacement.Pattern, err)
+		}
+		patterns[i] = re
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make(map[string]*Variable, len(variables))
+	renamedFrom := make(map[string]string, len(variables))
+	for _, name := range names {
+		item := variables[name]
+
+		newName, ok := mappings[name]
+		if !ok {
+			newName = name
+			for i, re := range patterns {
+				newName = re.ReplaceAllString(newName, rules.Replace[i].With)
+			}
+			newName = strings.TrimPrefix(newName, rules.StripPrefix)
+			newName = rules.AddPrefix + newName
+		}
+
+		if newName == "" {
+			return nil, fmt.Errorf("rename rules left %s with an empty name", name)
+		}
+		if other, exists := renamedFrom[newName]; exists {
+			return nil, fmt.Errorf("rename rules give both %s and %s the name %s",
+				describeVariable(other, variables[other]), describeVariable(name, item), newName)
+		}
+
+		renamed := *item
+		renamed.Key = newName
+		result[newName] = &renamed
+		renamedFrom[newName] = name
+	}
+
+	return result, nil
+}
+
+// Describe a variable by its name, and where it came from when known.
+func describeVariable(name string, item *Variable) string {
+	if arn := item.Metadata["arn"]; arn != "" {
+		return fmt.Sprintf("%s (%s)", name, arn)
+	}
+	return name
+}
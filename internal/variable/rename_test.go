@@ -0,0 +1,135 @@
+package variable
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Build a set of variables whose keys are their names.
+func testVariables(names ...string) map[string]*Variable {
+	variables := make(map[string]*Variable, len(names))
+	for _, name := range names {
+		variables[name] = &Variable{Key: name, Value: "value of " + name, Metadata: map[string]string{}}
+	}
+	return variables
+}
+
+// The names in a set of variables, sorted.
+func variableNames(variables map[string]*Variable) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRenameVariables(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules RenameRules
+		in    []string
+		want  []string
+	}{
+		{
+			name: "no rules",
+			in:   []string{"A", "B"},
+			want: []string{"A", "B"},
+		},
+		{
+			name:  "strip and add prefix",
+			rules: RenameRules{StripPrefix: "APP_", AddPrefix: "MY_"},
+			in:    []string{"APP_DB", "OTHER"},
+			want:  []string{"MY_DB", "MY_OTHER"},
+		},
+		{
+			name:  "replace in order",
+			rules: RenameRules{Replace: []RenameReplacement{{Pattern: `^db_(.*)$`, With: "DATABASE_${1}"}, {Pattern: "-", With: "_"}}},
+			in:    []string{"db_host-name", "port"},
+			want:  []string{"DATABASE_host_name", "port"},
+		},
+		{
+			name:  "mapping wins over other rules",
+			rules: RenameRules{Map: []RenameMapping{{From: "APP_DB", To: "DATABASE_URL"}}, StripPrefix: "APP_"},
+			in:    []string{"APP_DB", "APP_PORT"},
+			want:  []string{"DATABASE_URL", "PORT"},
+		},
+	}
+	for _, test := range tests {
+		got, err := RenameVariables(testVariables(test.in...), test.rules)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if names := variableNames(got); !reflect.DeepEqual(names, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, names, test.want)
+		}
+		for name, item := range got {
+			if item.Key != name {
+				t.Errorf("%s: %s has key %s", test.name, name, item.Key)
+			}
+		}
+	}
+}
+
+func TestRenameVariablesKeepsInput(t *testing.T) {
+	variables := testVariables("APP_DB")
+	if _, err := RenameVariables(variables, RenameRules{StripPrefix: "APP_"}); err != nil {
+		t.Fatal(err)
+	}
+	if variables["APP_DB"].Key != "APP_DB" {
+		t.Errorf("input variable was changed to %s", variables["APP_DB"].Key)
+	}
+}
+
+func TestRenameVariablesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   RenameRules
+		in      []string
+		wantErr string
+	}{
+		{
+			name:    "collision",
+			rules:   RenameRules{StripPrefix: "DEV_"},
+			in:      []string{"DEV_DB", "DB"},
+			wantErr: "both DB and DEV_DB",
+		},
+		{
+			name:    "mapping collision",
+			rules:   RenameRules{Map: []RenameMapping{{From: "A", To: "B"}}},
+			in:      []string{"A", "B"},
+			wantErr: "the name B",
+		},
+		{
+			name:    "empty name",
+			rules:   RenameRules{StripPrefix: "A"},
+			in:      []string{"A"},
+			wantErr: "empty name",
+		},
+		{
+			name:    "incomplete mapping",
+			rules:   RenameRules{Map: []RenameMapping{{From: "A"}}},
+			in:      []string{"A"},
+			wantErr: "need both",
+		},
+		{
+			name:    "invalid pattern",
+			rules:   RenameRules{Replace: []RenameReplacement{{Pattern: "("}}},
+			in:      []string{"A"},
+			wantErr: "invalid rename pattern",
+		},
+	}
+	for _, test := range tests {
+		// Repeated, since a collision shouldn't depend on map order.
+		for i := 0; i < 10; i++ {
+			_, err := RenameVariables(testVariables(test.in...), test.rules)
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: got error %v, want one containing %q", test.name, err, test.wantErr)
+				break
+			}
+		}
+	}
+}
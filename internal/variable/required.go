@@ -0,0 +1,46 @@
+package variable
+
+// Variables that must be present after fetching.
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RequiredVariable is a variable that must be present after fetching,
+// optionally with a value matching a pattern.
+type RequiredVariable struct {
+	Name string `mapstructure:"name"`
+
+	// Regular expression (RE2 syntax) the value must match.
+	Pattern string `mapstructure:"pattern"`
+}
+
+// CheckRequiredVariables returns a problem for every required variable
+// that is missing, or has a value that doesn't match its pattern.
+//
+// Values are never included in the problems.
+func CheckRequiredVariables(variables map[string]*Variable, required []RequiredVariable, opts FormatOptions) ([]string, error) {
+	problems := make([]string, 0)
+
+	for _, requirement := range required {
+		item, found := LookupVariable(variables, requirement.Name, opts)
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s is missing", requirement.Name))
+			continue
+		}
+		if requirement.Pattern == "" {
+			continue
+		}
+
+		pattern, err := regexp.Compile(requirement.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for %s: %w", requirement.Name, err)
+		}
+		if !pattern.MatchString(item.Value) {
+			problems = append(problems, fmt.Sprintf("%s doesn't match %s", requirement.Name, requirement.Pattern))
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,40 @@
+package variable
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckRequiredVariables(t *testing.T) {
+	variables := testVariables("DB_HOST", "DB_PORT")
+	variables["DB_PORT"].Value = "not-a-port"
+
+	required := []RequiredVariable{
+		{Name: "DB_HOST"},
+		{Name: "DB_PORT", Pattern: "^[0-9]+$"},
+		{Name: "API_KEY"},
+		{Name: "db_host", Pattern: "^value"},
+	}
+
+	problems, err := CheckRequiredVariables(variables, required, FormatOptions{Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DB_PORT doesn't match ^[0-9]+$", "API_KEY is missing"}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("got problems %v, want %v", problems, want)
+	}
+	for _, problem := range problems {
+		if strings.Contains(problem, "not-a-port") {
+			t.Errorf("problem %q shows the value", problem)
+		}
+	}
+}
+
+func TestCheckRequiredVariablesInvalidPattern(t *testing.T) {
+	required := []RequiredVariable{{Name: "DB_HOST", Pattern: "("}}
+	if _, err := CheckRequiredVariables(testVariables("DB_HOST"), required, FormatOptions{}); err == nil {
+		t.Error("an invalid pattern succeeded")
+	}
+}
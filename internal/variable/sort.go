@@ -0,0 +1,57 @@
+package variable
+
+// Deterministic ordering for formatted output.
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Supported output orders.
+const (
+	// Alphabetical by variable name.
+	Sort_Alpha = "alpha"
+	// Grouped by source, then by declared target order, then alphabetical.
+	Sort_Source = "source"
+)
+
+// Order that sources are grouped in, matching the order they are fetched.
+var sourceOrder = []string{Source_AwsSsmParameterStore, Source_AwsSecretsManager}
+
+// SortedNames returns the variable names in a stable output order.
+func SortedNames(variables map[string]*Variable, order string) ([]string, error) {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+
+	switch order {
+	case Sort_Alpha, "":
+		sort.Strings(names)
+	case Sort_Source:
+		sort.Slice(names, func(i, j int) bool {
+			a, b := variables[names[i]], variables[names[j]]
+			if rankA, rankB := sourceRank(a.Source), sourceRank(b.Source); rankA != rankB {
+				return rankA < rankB
+			}
+			if a.TargetIndex != b.TargetIndex {
+				return a.TargetIndex < b.TargetIndex
+			}
+			return names[i] < names[j]
+		})
+	default:
+		return nil, fmt.Errorf("unsupported sort order %q", order)
+	}
+
+	return names, nil
+}
+
+// Position of a source in the grouped output order. Unknown sources go last.
+func sourceRank(source string) int {
+	for i, known := range sourceOrder {
+		if source == known {
+			return i
+		}
+	}
+	return len(sourceOrder)
+}
@@ -0,0 +1,94 @@
+package variable
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// Compare output with a golden file in testdata, or rewrite it with -update.
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, []byte(got), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output doesn't match %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// Variables from both sources and several targets, declared out of
+// alphabetical order.
+func sourceVariables() map[string]*Variable {
+	variables := map[string]*Variable{
+		"ZONE":        {Source: Source_AwsSsmParameterStore, TargetIndex: 0},
+		"API_URL":     {Source: Source_AwsSsmParameterStore, TargetIndex: 0},
+		"DB_HOST":     {Source: Source_AwsSsmParameterStore, TargetIndex: 1},
+		"DB_PASSWORD": {Source: Source_AwsSecretsManager, TargetIndex: 0},
+		"CACHE_TOKEN": {Source: Source_AwsSecretsManager, TargetIndex: 1},
+		"ADMIN_TOKEN": {Source: Source_AwsSecretsManager, TargetIndex: 1},
+		"LOCAL":       {Source: "unknown"},
+	}
+	for name, item := range variables {
+		item.Key = name
+		item.Value = "value of " + name
+		item.Metadata = map[string]string{}
+	}
+	return variables
+}
+
+func TestSortedNames(t *testing.T) {
+	tests := map[string][]string{
+		Sort_Alpha:  {"ADMIN_TOKEN", "API_URL", "CACHE_TOKEN", "DB_HOST", "DB_PASSWORD", "LOCAL", "ZONE"},
+		"":          {"ADMIN_TOKEN", "API_URL", "CACHE_TOKEN", "DB_HOST", "DB_PASSWORD", "LOCAL", "ZONE"},
+		Sort_Source: {"API_URL", "ZONE", "DB_HOST", "DB_PASSWORD", "ADMIN_TOKEN", "CACHE_TOKEN", "LOCAL"},
+	}
+	for order, want := range tests {
+		got, err := SortedNames(sourceVariables(), order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SortedNames(%q) = %v, want %v", order, got, want)
+		}
+	}
+
+	if _, err := SortedNames(sourceVariables(), "random"); err == nil {
+		t.Error("SortedNames with an unknown order succeeded")
+	}
+}
+
+func TestSortedOutputGolden(t *testing.T) {
+	for _, order := range []string{Sort_Alpha, Sort_Source} {
+		first, err := Format(sourceVariables(), Format_Env, FormatOptions{Sort: order})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertGolden(t, "sort-"+order+".env", first)
+
+		// Map iteration order changes between runs, the output must not.
+		for i := 0; i < 20; i++ {
+			again, err := Format(sourceVariables(), Format_Env, FormatOptions{Sort: order})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != first {
+				t.Fatalf("%s output changed between runs:\n%s\nthen:\n%s", order, first, again)
+			}
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package variable
+
+// Structured (JSON, YAML, TOML) output formats.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A map that keeps its keys in insertion order.
+//
+// Values are either strings, or nested *orderedMap tables.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newOrderedMap() *orderedMap {
+	return &orderedMap{values: make(map[string]interface{}, 0)}
+}
+
+// Set a value, keeping the position of existing keys.
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Build the tree of values to encode, in output order.
+//
+// When nest is set, names are split by the separator into nested tables,
+// so "DB_HOST" becomes {"DB": {"HOST": ...}}. Names keep their case, so
+// lower case tables need the lower option.
+func variablesAsTree(variables map[string]*Variable, opts FormatOptions) (*orderedMap, error) {
+	// Names are sanitized before they are formatted, so other characters
+	// would never split them.
+	if opts.Nest && !envNameReplacementRegex.MatchString(opts.Separator) {
+		return nil, fmt.Errorf("invalid separator %q: variable names only have letters, digits and underscores to split on", opts.Separator)
+	}
+
+	names, err := SortedNames(variables, opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := newOrderedMap()
+	for _, name := range names {
+		key := formatEnvName(name, opts.Lower, opts.Upper)
+		value := variables[name].Value
+
+		if !opts.Nest || opts.Separator == "" {
+			tree.set(key, value)
+			continue
+		}
+
+		path := make([]string, 0)
+		for _, segment := range strings.Split(key, opts.Separator) {
+			if segment != "" {
+				path = append(path, segment)
+			}
+		}
+		if len(path) == 0 {
+			path = []string{key}
+		}
+
+		table := tree
+		for i, segment := range path[:len(path)-1] {
+			existing, exists := table.values[segment]
+			if !exists {
+				child := newOrderedMap()
+				table.set(segment, child)
+				table = child
+				continue
+			}
+			child, ok := existing.(*orderedMap)
+			if !ok {
+				conflict := strings.Join(path[:i+1], opts.Separator)
+				return nil, fmt.Errorf("can't nest %s, because %s already has a value", key, conflict)
+			}
+			table = child
+		}
+
+		leaf := path[len(path)-1]
+		if _, exists := table.values[leaf]; exists {
+			return nil, fmt.Errorf("can't nest %s, because another variable already uses that name", key)
+		}
+		table.set(leaf, value)
+	}
+
+	return tree, nil
+}
+
+// Format a set of variables as a JSON object.
+func VariablesAsJSON(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	tree, err := variablesAsTree(variables, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := writeJSON(&buffer, tree, ""); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Write an ordered map as indented JSON.
+func writeJSON(buffer *bytes.Buffer, m *orderedMap, indent string) error {
+	if len(m.keys) == 0 {
+		buffer.WriteString("{}")
+		return nil
+	}
+
+	buffer.WriteString("{\n")
+	for i, key := range m.keys {
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(indent + "  ")
+		buffer.Write(encodedKey)
+		buffer.WriteString(": ")
+
+		switch value := m.values[key].(type) {
+		case *orderedMap:
+			if err := writeJSON(buffer, value, indent+"  "); err != nil {
+				return err
+			}
+		default:
+			encodedValue, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buffer.Write(encodedValue)
+		}
+
+		if i < len(m.keys)-1 {
+			buffer.WriteString(",")
+		}
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString(indent + "}")
+
+	return nil
+}
+
+// Format a set of variables as a YAML document.
+func VariablesAsYAML(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	tree, err := variablesAsTree(variables, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlNode(tree)); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// Convert an ordered map to a YAML mapping node, keeping the key order.
+func yamlNode(m *orderedMap) *yaml.Node {
+	node := yamlMapping()
+	for _, key := range m.keys {
+		switch value := m.values[key].(type) {
+		case *orderedMap:
+			yamlAppend(node, key, yamlNode(value))
+		case string:
+			yamlAppend(node, key, yamlString(value))
+		}
+	}
+	return node
+}
+
+// Create an empty YAML mapping node.
+func yamlMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// Create a YAML string node.
+func yamlString(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// Create a YAML mapping node from a map, with sorted keys.
+func yamlStringMap(values map[string]string) *yaml.Node {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	node := yamlMapping()
+	for _, key := range keys {
+		yamlAppend(node, key, yamlString(values[key]))
+	}
+	return node
+}
+
+// Append a key/value pair to a YAML mapping node.
+func yamlAppend(mapping *yaml.Node, key string, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, yamlString(key), value)
+}
+
+// Format a set of variables as a TOML document.
+func VariablesAsTOML(variables map[string]*Variable, opts FormatOptions) (string, error) {
+	tree, err := variablesAsTree(variables, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	writeTOMLTable(&buffer, tree, nil)
+
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// Write an ordered map as a TOML table.
+//
+// A table's own key/value pairs have to come before any of its sub-tables.
+// Tables that only hold sub-tables don't need a header of their own.
+func writeTOMLTable(buffer *bytes.Buffer, m *orderedMap, path []string) {
+	tables := make([]string, 0)
+	headerWritten := len(path) == 0
+
+	for _, key := range m.keys {
+		switch value := m.values[key].(type) {
+		case *orderedMap:
+			tables = append(tables, key)
+		case string:
+			if !headerWritten {
+				writeTOMLHeader(buffer, path)
+				headerWritten = true
+			}
+			buffer.WriteString(fmt.Sprintf("%s = %s\n", tomlKey(key), tomlString(value)))
+		}
+	}
+
+	for _, key := range tables {
+		tablePath := append(append([]string{}, path...), key)
+		writeTOMLTable(buffer, m.values[key].(*orderedMap), tablePath)
+	}
+}
+
+// Write a TOML table header, separated from any previous content.
+func writeTOMLHeader(buffer *bytes.Buffer, path []string) {
+	quotedPath := make([]string, len(path))
+	for i, segment := range path {
+		quotedPath[i] = tomlKey(segment)
+	}
+
+	if buffer.Len() > 0 {
+		buffer.WriteString("\n")
+	}
+	buffer.WriteString(fmt.Sprintf("[%s]\n", strings.Join(quotedPath, ".")))
+}
+
+// TOML bare keys can only contain ASCII letters, digits, underscores and dashes.
+var tomlBareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Format a TOML key, quoting it when it can't be a bare key.
+func tomlKey(key string) string {
+	if tomlBareKeyRegex.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// Format a TOML basic string, escaping quotes, backslashes and control characters.
+func tomlString(value string) string {
+	var result strings.Builder
+	result.WriteString(`"`)
+	for _, c := range value {
+		switch c {
+		case '"':
+			result.WriteString(`\"`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\b':
+			result.WriteString(`\b`)
+		case '\t':
+			result.WriteString(`\t`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\f':
+			result.WriteString(`\f`)
+		case '\r':
+			result.WriteString(`\r`)
+		default:
+			if c < 0x20 || c == 0x7f {
+				result.WriteString(fmt.Sprintf(`\u%04X`, c))
+			} else {
+				result.WriteRune(c)
+			}
+		}
+	}
+	result.WriteString(`"`)
+	return result.String()
+}
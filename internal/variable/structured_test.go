@@ -0,0 +1,132 @@
+package variable
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Variables whose names nest, with values that need escaping.
+func nestedVariables() map[string]*Variable {
+	variables := testVariables("DB_HOST", "DB_PORT", "DB_REPLICA_HOST", "API_KEY", "TIMEOUT")
+	variables["DB_HOST"].Value = "db.internal"
+	variables["DB_PORT"].Value = "5432"
+	variables["DB_REPLICA_HOST"].Value = "replica.internal"
+	variables["API_KEY"].Value = "quote\" back\\slash\ttab\nnewline"
+	variables["TIMEOUT"].Value = "true"
+	return variables
+}
+
+func TestStructuredFormatsGolden(t *testing.T) {
+	for _, format := range []string{Format_JSON, Format_YAML, Format_TOML} {
+		for _, nest := range []bool{false, true} {
+			output, err := Format(nestedVariables(), format, FormatOptions{Nest: nest, Separator: "_"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := format
+			if nest {
+				name += "-nested"
+			}
+			assertGolden(t, name+"."+format, output)
+		}
+	}
+}
+
+func TestStructuredFormatsRoundTrip(t *testing.T) {
+	want := make(map[string]interface{})
+	for name, item := range nestedVariables() {
+		want[name] = item.Value
+	}
+
+	output, err := VariablesAsJSON(nestedVariables(), FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON read back %v, want %v", got, want)
+	}
+
+	// Values that look like other YAML types have to stay strings.
+	output, err = VariablesAsYAML(nestedVariables(), FormatOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = make(map[string]interface{})
+	if err := yaml.Unmarshal([]byte(output), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("YAML read back %v, want %v", got, want)
+	}
+}
+
+func TestStructuredFormatsEmpty(t *testing.T) {
+	tests := map[string]string{Format_JSON: "{}", Format_YAML: "{}", Format_TOML: ""}
+	for format, want := range tests {
+		output, err := Format(map[string]*Variable{}, format, FormatOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != want {
+			t.Errorf("empty %s output is %q, want %q", format, output, want)
+		}
+	}
+}
+
+func TestNestConflicts(t *testing.T) {
+	tests := map[string][]string{
+		"value and table":         {"DB", "DB_HOST"},
+		"same name after nesting": {"DB_HOST", "DB__HOST"},
+	}
+	for name, names := range tests {
+		_, err := VariablesAsJSON(testVariables(names...), FormatOptions{Nest: true, Separator: "_"})
+		if err == nil || !strings.Contains(err.Error(), "can't nest") {
+			t.Errorf("%s: got error %v, want a nesting conflict", name, err)
+		}
+	}
+}
+
+func TestNestSeparators(t *testing.T) {
+	variables := testVariables("DB__HOST_NAME", "DB__PORT")
+
+	output, err := VariablesAsJSON(variables, FormatOptions{Nest: true, Separator: "__", Lower: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"db\": {\n    \"host_name\": \"value of DB__HOST_NAME\",\n    \"port\": \"value of DB__PORT\"\n  }\n}"
+	if output != want {
+		t.Errorf("got %q, want %q", output, want)
+	}
+
+	// Sanitized names never have these, so nothing would be nested.
+	for _, separator := range []string{".", "/", "-"} {
+		_, err := VariablesAsJSON(variables, FormatOptions{Nest: true, Separator: separator})
+		if err == nil || !strings.Contains(err.Error(), "invalid separator") {
+			t.Errorf("separator %q: got error %v, want an invalid separator", separator, err)
+		}
+	}
+}
+
+func TestTomlKeysAndStrings(t *testing.T) {
+	keys := map[string]string{"DB_HOST": "DB_HOST", "a-b": "a-b", "a.b": `"a.b"`, "": `""`}
+	for key, want := range keys {
+		if got := tomlKey(key); got != want {
+			t.Errorf("tomlKey(%q) = %s, want %s", key, got, want)
+		}
+	}
+
+	values := map[string]string{"plain": `"plain"`, "a\"b\\c": `"a\"b\\c"`, "\x01\x7f": `"\u0001\u007F"`}
+	for value, want := range values {
+		if got := tomlString(value); got != want {
+			t.Errorf("tomlString(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
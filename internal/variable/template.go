@@ -0,0 +1,98 @@
+package variable
+
+// Go text/template rendering of arbitrary files.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// RenderTemplate renders a Go text/template with the fetched variables.
+//
+// The template data maps each variable name to its *Variable, so values are
+// referenced as {{ .DB_HOST.Value }}, along with .Source and .Metadata.
+// Names are the same as in the other outputs, after the lower/upper options.
+// Referencing a missing variable is an error, so optional variables are
+// looked up with index, which the required and default helpers accept:
+// {{ index . "PORT" | default "8080" }}.
+func RenderTemplate(name string, text string, variables map[string]*Variable, opts FormatOptions) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(templateFuncs()).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := make(map[string]*Variable, len(variables))
+	for varName, item := range variables {
+		data[formatEnvName(varName, opts.Lower, opts.Upper)] = item
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Helper functions available in templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"b64enc": func(value interface{}) string {
+			text, _ := templateString(value)
+			return base64.StdEncoding.EncodeToString([]byte(text))
+		},
+		"b64dec": func(value interface{}) (string, error) {
+			text, _ := templateString(value)
+			decoded, err := base64.StdEncoding.DecodeString(text)
+			if err != nil {
+				return "", fmt.Errorf("b64dec: %w", err)
+			}
+			return string(decoded), nil
+		},
+		"json": func(value interface{}) (string, error) {
+			if text, ok := templateString(value); ok {
+				value = text
+			}
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("json: %w", err)
+			}
+			return string(encoded), nil
+		},
+		"required": func(message string, value interface{}) (string, error) {
+			text, ok := templateString(value)
+			if !ok || text == "" {
+				return "", fmt.Errorf("%s", message)
+			}
+			return text, nil
+		},
+		"default": func(fallback string, value interface{}) string {
+			text, ok := templateString(value)
+			if !ok || text == "" {
+				return fallback
+			}
+			return text
+		},
+	}
+}
+
+// Get the string value of a template argument, which is either a *Variable
+// or a plain value. Reports false for missing variables and nil values.
+func templateString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case *Variable:
+		if v == nil {
+			return "", false
+		}
+		return v.Value, true
+	case string:
+		return v, true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
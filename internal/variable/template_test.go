@@ -0,0 +1,97 @@
+package variable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	variables := testVariables("DB_HOST", "TOKEN", "EMPTY")
+	variables["DB_HOST"].Value = "db.internal"
+	variables["DB_HOST"].Source = Source_AwsSsmParameterStore
+	variables["DB_HOST"].Metadata["path"] = "/app/db/host"
+	variables["TOKEN"].Value = `a"b`
+	variables["EMPTY"].Value = ""
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "value", template: "host={{ .DB_HOST.Value }}", want: "host=db.internal"},
+		{name: "source", template: "{{ .DB_HOST.Source }}", want: Source_AwsSsmParameterStore},
+		{name: "metadata", template: `{{ index .DB_HOST.Metadata "path" }}`, want: "/app/db/host"},
+		{name: "b64enc", template: "{{ b64enc .DB_HOST }}", want: "ZGIuaW50ZXJuYWw="},
+		{name: "b64dec", template: `{{ b64dec "ZGIuaW50ZXJuYWw=" }}`, want: "db.internal"},
+		{name: "json variable", template: "{{ json .TOKEN }}", want: `"a\"b"`},
+		{name: "json value", template: "{{ json .TOKEN.Value }}", want: `"a\"b"`},
+		{name: "default for missing", template: `{{ index . "PORT" | default "8080" }}`, want: "8080"},
+		{name: "default for empty", template: `{{ .EMPTY | default "none" }}`, want: "none"},
+		{name: "default unused", template: `{{ .DB_HOST | default "localhost" }}`, want: "db.internal"},
+		{name: "required", template: `{{ .DB_HOST | required "DB_HOST is required" }}`, want: "db.internal"},
+		{name: "range", template: "{{ range $name, $v := . }}{{ $name }};{{ end }}", want: "DB_HOST;EMPTY;TOKEN;"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := RenderTemplate("test", test.template, variables, FormatOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateCase(t *testing.T) {
+	variables := testVariables("db_host", "Api_Key")
+
+	tests := []struct {
+		opts     FormatOptions
+		template string
+		want     string
+	}{
+		{opts: FormatOptions{Upper: true}, template: "{{ .DB_HOST.Value }} {{ .API_KEY.Value }}", want: "value of db_host value of Api_Key"},
+		{opts: FormatOptions{Lower: true}, template: "{{ .db_host.Value }} {{ .api_key.Value }}", want: "value of db_host value of Api_Key"},
+		{opts: FormatOptions{}, template: "{{ .db_host.Value }} {{ .Api_Key.Value }}", want: "value of db_host value of Api_Key"},
+	}
+
+	for _, test := range tests {
+		got, err := RenderTemplate("test", test.template, variables, test.opts)
+		if err != nil {
+			t.Errorf("%+v: %s", test.opts, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %q, want %q", test.opts, got, test.want)
+		}
+	}
+
+	// The name before folding isn't there anymore.
+	if _, err := RenderTemplate("test", "{{ .db_host.Value }}", variables, FormatOptions{Upper: true}); err == nil {
+		t.Error("rendering the unfolded name succeeded, want an error")
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	variables := testVariables("DB_HOST")
+
+	tests := map[string]string{
+		"syntax":           "{{ .DB_HOST.Value ",
+		"missing variable": "{{ .PORT.Value }}",
+		"required missing": `{{ index . "PORT" | required "PORT is required" }}`,
+		"bad base64":       `{{ b64dec "not base64!" }}`,
+	}
+	for name, text := range tests {
+		if _, err := RenderTemplate("test", text, variables, FormatOptions{}); err == nil {
+			t.Errorf("%s: rendering succeeded, want an error", name)
+		}
+	}
+
+	_, err := RenderTemplate("test", `{{ index . "PORT" | required "PORT is required" }}`, variables, FormatOptions{})
+	if err == nil || !strings.Contains(err.Error(), "PORT is required") {
+		t.Errorf("got error %v, want the required message", err)
+	}
+}